@@ -9,6 +9,8 @@ import (
 	"github.com/ethaan/discord-api/pkg/database"
 	"github.com/ethaan/discord-api/pkg/discord"
 	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/tibia"
 )
 
 func main() {
@@ -34,12 +36,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	if indexed, err := repositories.NewSessionTransitionRepository().Backfill(); err != nil {
+		logger.Error("Failed to index session transitions: %v", err)
+		os.Exit(1)
+	} else if indexed > 0 {
+		logger.Success("Indexed %d session transitions", indexed)
+	}
+
 	if err := database.InitializeGuildConfig(cfg.DiscordGuildID, cfg.ParentCategoryID, nil); err != nil {
 		logger.Error("Failed to initialize guild config: %v", err)
 		os.Exit(1)
 	}
 
-	bot, err := discord.New(cfg.DiscordToken, cfg.DiscordGuildID, cfg.TibiaAPIURL)
+	var cache tibia.Cache = tibia.NewMemoryCache(cfg.TibiaCache.Size)
+	if cfg.TibiaCache.Persistent {
+		cache = tibia.NewTieredCache(cache, repositories.NewAPICacheRepository())
+	}
+	tibiaClient := tibia.NewClientWithOptions(cfg.TibiaAPIURL, tibia.ClientOptions{
+		Cache:             cache,
+		RequestsPerSecond: float64(cfg.TibiaRateLimit),
+		Burst:             cfg.TibiaRateLimit * 2,
+	})
+
+	bot, err := discord.New(cfg.DiscordToken, cfg.DiscordGuildID, tibiaClient, cfg.TrackedWorlds, cfg.SessionRetention)
 	if err != nil {
 		logger.Error("Failed to create Discord bot: %v", err)
 		os.Exit(1)
@@ -50,11 +69,21 @@ func main() {
 	bot.RegisterCommand(discord.CloseListCommand())
 	bot.RegisterCommand(discord.AddCommand())
 	bot.RegisterCommand(discord.AddByGuildCommand())
+	bot.RegisterCommand(discord.AddExpLockCommand())
+	bot.RegisterCommand(discord.WatchGuildCommand())
 	bot.RegisterCommand(discord.ListCommand())
 	bot.RegisterCommand(discord.RemoveCommand())
 	bot.RegisterCommand(discord.EnableEveryoneCommand())
 	bot.RegisterCommand(discord.DisableEveryoneCommand())
+	bot.RegisterCommand(discord.SetMinLevelDeltaCommand())
 	bot.RegisterCommand(discord.ScanCommand())
+	bot.RegisterCommand(discord.ScanExplainCommand())
+	bot.RegisterCommand(discord.ScanDefaultsCommand())
+	bot.RegisterCommand(discord.LastSeenCommand())
+	bot.RegisterCommand(discord.PlaytimeCommand())
+	bot.RegisterCommand(discord.ActivityCommand())
+	bot.RegisterCommand(discord.ClusterCommand())
+	bot.RegisterComponent(discord.ScanLabelComponent())
 
 	if err := bot.Start(); err != nil {
 		logger.Error("Failed to start Discord bot: %v", err)
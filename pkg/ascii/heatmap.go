@@ -0,0 +1,72 @@
+package ascii
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ethaan/discord-api/pkg/repositories"
+)
+
+var weekdayLabels = [7]string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// heatmapShades go from no activity to the busiest cell in the grid.
+var heatmapShades = []string{"  ", "░░", "▒▒", "▓▓", "██"}
+
+// BuildActivityHeatmap renders an ActivityGrid as rows of weekdays and two
+// character columns per hour, shaded relative to the busiest hour.
+func BuildActivityHeatmap(grid *repositories.ActivityGrid) string {
+	var busiest float64
+	for _, day := range grid {
+		for _, minutes := range day {
+			if minutes > busiest {
+				busiest = minutes
+			}
+		}
+	}
+
+	var b strings.Builder
+
+	b.WriteString("    ")
+	for hour := 0; hour < 24; hour += 3 {
+		b.WriteString(padRight(strconv.Itoa(hour), 6))
+	}
+	b.WriteString("\n")
+
+	for day, hours := range grid {
+		b.WriteString(weekdayLabels[day])
+		b.WriteString(" ")
+		for _, minutes := range hours {
+			b.WriteString(heatmapShades[shadeIndex(minutes, busiest, len(heatmapShades))])
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n    ")
+	for _, shade := range heatmapShades[1:] {
+		b.WriteString(shade)
+		b.WriteString(" ")
+	}
+	b.WriteString("low → high\n")
+
+	return b.String()
+}
+
+// shadeIndex maps minutes to one of levels buckets. Zero stays in the first
+// bucket so idle hours remain blank.
+func shadeIndex(minutes, busiest float64, levels int) int {
+	if minutes <= 0 || busiest <= 0 {
+		return 0
+	}
+	idx := 1 + int(minutes/busiest*float64(levels-1))
+	if idx >= levels {
+		idx = levels - 1
+	}
+	return idx
+}
+
+func padRight(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
@@ -0,0 +1,57 @@
+package ascii
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethaan/discord-api/pkg/repositories"
+)
+
+func TestShadeIndex(t *testing.T) {
+	tests := []struct {
+		minutes, busiest float64
+		want             int
+	}{
+		{minutes: 0, busiest: 60, want: 0},
+		{minutes: 5, busiest: 0, want: 0},
+		{minutes: 1, busiest: 60, want: 1},
+		{minutes: 20, busiest: 60, want: 2},
+		{minutes: 40, busiest: 60, want: 3},
+		{minutes: 59, busiest: 60, want: 4},
+		{minutes: 60, busiest: 60, want: 4},
+	}
+
+	for _, tt := range tests {
+		if got := shadeIndex(tt.minutes, tt.busiest, len(heatmapShades)); got != tt.want {
+			t.Errorf("shadeIndex(%g, %g) = %d, want %d", tt.minutes, tt.busiest, got, tt.want)
+		}
+	}
+}
+
+func TestBuildActivityHeatmap(t *testing.T) {
+	var grid repositories.ActivityGrid
+	grid[0][0] = 60  // Monday 00:00, the busiest hour
+	grid[6][23] = 15 // Sunday 23:00
+
+	lines := strings.Split(BuildActivityHeatmap(&grid), "\n")
+
+	// Hour header, seven weekdays, a blank line and the legend
+	if len(lines) < 10 {
+		t.Fatalf("got %d lines:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+
+	monday := lines[1]
+	if !strings.HasPrefix(monday, "Mon ██") {
+		t.Errorf("Monday row = %q, want it to start with the busiest shade", monday)
+	}
+
+	sunday := lines[7]
+	if !strings.HasPrefix(sunday, "Sun ") || !strings.HasSuffix(sunday, "▒▒") {
+		t.Errorf("Sunday row = %q, want it to end with a mid shade", sunday)
+	}
+
+	tuesday := lines[2]
+	if strings.TrimSpace(strings.TrimPrefix(tuesday, "Tue")) != "" {
+		t.Errorf("Tuesday row = %q, want it blank", tuesday)
+	}
+}
@@ -3,7 +3,9 @@ package ascii
 import (
 	"bytes"
 	"fmt"
+	"time"
 
+	"github.com/ethaan/discord-api/pkg/database"
 	"github.com/ethaan/discord-api/pkg/repositories"
 	"github.com/ethaan/discord-api/pkg/tibia"
 	"github.com/olekukonko/tablewriter"
@@ -41,7 +43,7 @@ func BuildTextTableForPowergamers(powergamers []tibia.Powergamer) string {
 	return buf.String()
 }
 
-func BuildScanResultsTable(results []repositories.ScanResult, veryHighThreshold, highThreshold, mediumThreshold int) string {
+func BuildScanResultsTable(results []repositories.ScanResult, veryHighScore, highScore, mediumScore float64) string {
 	buf := new(bytes.Buffer)
 
 	table := tablewriter.NewWriter(buf)
@@ -50,19 +52,19 @@ func BuildScanResultsTable(results []repositories.ScanResult, veryHighThreshold,
 		tablewriter.WithRowAlignment(tw.AlignLeft),
 	)
 
-	table.Header("Character Name", "Transitions", "Confidence")
+	table.Header("Character Name", "Transitions", "Expected", "Score", "Confidence")
 
 	for _, r := range results {
 		var confidence string
 		var emoji string
 
-		if r.AdjacentCount >= veryHighThreshold {
+		if r.Score >= veryHighScore {
 			emoji = "🔴"
 			confidence = "Very High"
-		} else if r.AdjacentCount >= highThreshold {
+		} else if r.Score >= highScore {
 			emoji = "🟠"
 			confidence = "High"
-		} else if r.AdjacentCount >= mediumThreshold {
+		} else if r.Score >= mediumScore {
 			emoji = "🟡"
 			confidence = "Medium"
 		} else {
@@ -70,9 +72,16 @@ func BuildScanResultsTable(results []repositories.ScanResult, veryHighThreshold,
 			confidence = "Low"
 		}
 
+		if r.Label == repositories.ScanLabelConfirmed {
+			emoji = "✅"
+			confidence = "Confirmed"
+		}
+
 		table.Append([]string{
 			r.CharacterName,
 			fmt.Sprintf("%d", r.AdjacentCount),
+			fmt.Sprintf("%.1f", r.ExpectedCount),
+			fmt.Sprintf("%.1f", r.Score),
 			fmt.Sprintf("%s %s", emoji, confidence),
 		})
 	}
@@ -80,3 +89,95 @@ func BuildScanResultsTable(results []repositories.ScanResult, veryHighThreshold,
 	table.Render()
 	return buf.String()
 }
+
+// BuildScanEvidenceTable lists scan transitions, newest first, with times in
+// loc. T marks the target and C the candidate.
+func BuildScanEvidenceTable(transitions []repositories.ScanTransition, loc *time.Location) string {
+	buf := new(bytes.Buffer)
+
+	table := tablewriter.NewWriter(buf)
+	table.Options(
+		tablewriter.WithRowAutoWrap(0),
+		tablewriter.WithRowAlignment(tw.AlignLeft),
+	)
+
+	table.Header("Logout", "Login", "Order", "Gap", "Counted")
+
+	for _, t := range transitions {
+		order := "C → T"
+		if t.TargetFirst {
+			order = "T → C"
+		}
+
+		counted := "✅"
+		if t.Uncertain {
+			counted = "❔"
+		}
+
+		table.Append([]string{
+			t.LogoutAt.In(loc).Format("01-02 15:04:05"),
+			t.LoginAt.In(loc).Format("15:04:05"),
+			order,
+			fmt.Sprintf("%+.0fs", t.GapSeconds),
+			counted,
+		})
+	}
+
+	table.Render()
+	return buf.String()
+}
+
+// BuildClusterMembersTable lists the members of an alt cluster.
+func BuildClusterMembersTable(members []database.Player) string {
+	buf := new(bytes.Buffer)
+
+	table := tablewriter.NewWriter(buf)
+	table.Options(
+		tablewriter.WithRowAutoWrap(0),
+		tablewriter.WithRowAlignment(tw.AlignLeft),
+	)
+
+	table.Header("Character Name", "Level", "Vocation")
+
+	for _, member := range members {
+		table.Append([]string{
+			member.Name,
+			fmt.Sprintf("%d", member.Level),
+			member.Vocation,
+		})
+	}
+
+	table.Render()
+	return buf.String()
+}
+
+// BuildClusterEdgesTable lists the edges of an alt cluster, naming players
+// through names. Edges whose players were ever online together are marked.
+func BuildClusterEdgesTable(edges []database.AltEdge, names map[uint]string) string {
+	buf := new(bytes.Buffer)
+
+	table := tablewriter.NewWriter(buf)
+	table.Options(
+		tablewriter.WithRowAutoWrap(0),
+		tablewriter.WithRowAlignment(tw.AlignLeft),
+	)
+
+	table.Header("Link", "Transitions", "Score", "Overlap")
+
+	for _, edge := range edges {
+		overlap := "-"
+		if edge.Overlaps > 0 {
+			overlap = fmt.Sprintf("⚠️ %d", edge.Overlaps)
+		}
+
+		table.Append([]string{
+			fmt.Sprintf("%s ↔ %s", names[edge.PlayerA], names[edge.PlayerB]),
+			fmt.Sprintf("%d", edge.Transitions),
+			fmt.Sprintf("%.1f", edge.Score),
+			overlap,
+		})
+	}
+
+	table.Render()
+	return buf.String()
+}
@@ -0,0 +1,87 @@
+package charts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethaan/discord-api/pkg/repositories"
+)
+
+type clusterNode struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	World    string `json:"world"`
+	Level    int    `json:"level"`
+	Vocation string `json:"vocation"`
+}
+
+type clusterEdge struct {
+	Source      uint    `json:"source"`
+	Target      uint    `json:"target"`
+	Transitions int     `json:"transitions"`
+	Overlaps    int     `json:"overlaps"`
+	Score       float64 `json:"score"`
+}
+
+type clusterGraph struct {
+	ClusterID uint          `json:"cluster_id"`
+	Nodes     []clusterNode `json:"nodes"`
+	Edges     []clusterEdge `json:"edges"`
+}
+
+// ClusterJSON exports a cluster as a node/edge list, the shape most graph
+// libraries (d3, cytoscape, networkx) import directly.
+func ClusterJSON(cluster *repositories.AltCluster) ([]byte, error) {
+	graph := clusterGraph{
+		ClusterID: cluster.ID,
+		Nodes:     make([]clusterNode, 0, len(cluster.Members)),
+		Edges:     make([]clusterEdge, 0, len(cluster.Edges)),
+	}
+
+	for _, member := range cluster.Members {
+		graph.Nodes = append(graph.Nodes, clusterNode{
+			ID:       member.ID,
+			Name:     member.Name,
+			World:    member.World,
+			Level:    member.Level,
+			Vocation: member.Vocation,
+		})
+	}
+
+	for _, edge := range cluster.Edges {
+		graph.Edges = append(graph.Edges, clusterEdge{
+			Source:      edge.PlayerA,
+			Target:      edge.PlayerB,
+			Transitions: edge.Transitions,
+			Overlaps:    edge.Overlaps,
+			Score:       edge.Score,
+		})
+	}
+
+	return json.MarshalIndent(graph, "", "  ")
+}
+
+// ClusterDOT exports a cluster as a Graphviz graph. Edge width follows the
+// score, and edges whose players were online together are drawn dashed red.
+func ClusterDOT(cluster *repositories.AltCluster) []byte {
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "graph cluster_%d {\n", cluster.ID)
+	buf.WriteString("  node [shape=box, style=rounded];\n")
+
+	for _, member := range cluster.Members {
+		fmt.Fprintf(buf, "  p%d [label=%q];\n", member.ID, fmt.Sprintf("%s\n%d %s", member.Name, member.Level, member.Vocation))
+	}
+
+	for _, edge := range cluster.Edges {
+		attrs := fmt.Sprintf("label=%q, penwidth=%.1f", fmt.Sprintf("%d / %.1f", edge.Transitions, edge.Score), 1+edge.Score/10)
+		if edge.Overlaps > 0 {
+			attrs += ", style=dashed, color=red"
+		}
+		fmt.Fprintf(buf, "  p%d -- p%d [%s];\n", edge.PlayerA, edge.PlayerB, attrs)
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,58 @@
+package charts
+
+import (
+	"image"
+	"image/color"
+)
+
+// glyphs is a 3x5 pixel font covering the characters used in chart labels.
+// Each row is three bits, most significant bit on the left.
+var glyphs = map[rune][5]uint8{
+	'0': {0b111, 0b101, 0b101, 0b101, 0b111},
+	'1': {0b010, 0b110, 0b010, 0b010, 0b111},
+	'2': {0b111, 0b001, 0b111, 0b100, 0b111},
+	'3': {0b111, 0b001, 0b111, 0b001, 0b111},
+	'4': {0b101, 0b101, 0b111, 0b001, 0b001},
+	'5': {0b111, 0b100, 0b111, 0b001, 0b111},
+	'6': {0b111, 0b100, 0b111, 0b101, 0b111},
+	'7': {0b111, 0b001, 0b010, 0b010, 0b010},
+	'8': {0b111, 0b101, 0b111, 0b101, 0b111},
+	'9': {0b111, 0b101, 0b111, 0b001, 0b111},
+	'M': {0b101, 0b111, 0b111, 0b101, 0b101},
+	'T': {0b111, 0b010, 0b010, 0b010, 0b010},
+	'W': {0b101, 0b101, 0b111, 0b111, 0b101},
+	'F': {0b111, 0b100, 0b110, 0b100, 0b100},
+	'S': {0b111, 0b100, 0b111, 0b001, 0b111},
+}
+
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+)
+
+// drawText draws text with its top-left corner at (x, y), each font pixel
+// scaled to a scale×scale square. Unknown characters are left blank.
+func drawText(img *image.RGBA, x, y int, text string, scale int, c color.Color) {
+	for _, r := range text {
+		rows, ok := glyphs[r]
+		if ok {
+			for row, bits := range rows {
+				for col := 0; col < glyphWidth; col++ {
+					if bits&(1<<(glyphWidth-1-col)) == 0 {
+						continue
+					}
+					fillRect(img, x+col*scale, y+row*scale, scale, scale, c)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+	for py := y; py < y+h; py++ {
+		for px := x; px < x+w; px++ {
+			img.Set(px, py, c)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/ethaan/discord-api/pkg/repositories"
+)
+
+const (
+	cellSize    = 24
+	cellGap     = 2
+	labelScale  = 2
+	leftMargin  = 28
+	topMargin   = 24
+	rightMargin = 8
+	bottomPad   = 8
+)
+
+var (
+	backgroundColor = color.RGBA{0x2B, 0x2D, 0x31, 0xFF}
+	idleColor       = color.RGBA{0x38, 0x3A, 0x40, 0xFF}
+	busyColor       = color.RGBA{0x57, 0xF2, 0x87, 0xFF}
+	labelColor      = color.RGBA{0xB5, 0xBA, 0xC1, 0xFF}
+)
+
+var weekdayInitials = [7]string{"M", "T", "W", "T", "F", "S", "S"}
+
+// ActivityHeatmapPNG renders an ActivityGrid as a PNG, one row per weekday
+// and one column per hour, colored relative to the busiest hour.
+func ActivityHeatmapPNG(grid *repositories.ActivityGrid) ([]byte, error) {
+	width := leftMargin + 24*(cellSize+cellGap) + rightMargin
+	height := topMargin + 7*(cellSize+cellGap) + bottomPad
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, 0, 0, width, height, backgroundColor)
+
+	var busiest float64
+	for _, day := range grid {
+		for _, minutes := range day {
+			if minutes > busiest {
+				busiest = minutes
+			}
+		}
+	}
+
+	for hour := 0; hour < 24; hour += 3 {
+		drawText(img, leftMargin+hour*(cellSize+cellGap), 6, fmt.Sprintf("%d", hour), labelScale, labelColor)
+	}
+
+	for day, hours := range grid {
+		y := topMargin + day*(cellSize+cellGap)
+		drawText(img, 8, y+(cellSize-glyphHeight*labelScale)/2, weekdayInitials[day], labelScale, labelColor)
+
+		for hour, minutes := range hours {
+			x := leftMargin + hour*(cellSize+cellGap)
+			cellColor := idleColor
+			if minutes > 0 && busiest > 0 {
+				cellColor = blend(idleColor, busyColor, 0.2+0.8*minutes/busiest)
+			}
+			fillRect(img, x, y, cellSize, cellSize, cellColor)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode heatmap: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// blend linearly interpolates from a to b, with t in [0, 1].
+func blend(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xFF}
+}
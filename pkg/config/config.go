@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -15,9 +16,35 @@ type Config struct {
 	DiscordGuildID   string
 	ParentCategoryID string
 	TibiaAPIURL      string
+	TibiaCache       TibiaCacheConfig
+	// TibiaRateLimit is the number of requests per second allowed to the
+	// Tibia API across all workers, jobs and commands.
+	TibiaRateLimit int
+	// TrackedWorlds are the worlds followed by the online tracker. Empty
+	// means the API deployment's default world.
+	TrackedWorlds    []string
+	SessionRetention SessionRetentionConfig
 	Database         DatabaseConfig
 }
 
+type TibiaCacheConfig struct {
+	// Size is the number of responses kept in the in-memory LRU.
+	Size int
+	// Persistent additionally stores responses in Postgres so they survive restarts.
+	Persistent bool
+}
+
+type SessionRetentionConfig struct {
+	// Days is how long raw online sessions are kept before they are rolled
+	// up into daily per-player activity. Zero, the default, keeps them
+	// forever.
+	Days int
+	// Archive moves rolled-up sessions to the partitioned archive table
+	// instead of deleting them. Without it, /activity and the overlap checks
+	// of scans lose the sessions older than Days.
+	Archive bool
+}
+
 type DatabaseConfig struct {
 	Host     string
 	Port     string
@@ -58,7 +85,17 @@ func Load() (*Config, error) {
 		DiscordGuildID:   getEnv("DISCORD_GUILD_ID", ""),
 		ParentCategoryID: getEnv("PARENT_CATEGORY_ID", ""),
 		TibiaAPIURL:      getEnv("TIBIA_API_URL", "https://api.tibiadata.com/v4"),
-		Database:         dbConfig,
+		TibiaCache: TibiaCacheConfig{
+			Size:       getEnvInt("TIBIA_CACHE_SIZE", 5000),
+			Persistent: getEnv("TIBIA_CACHE_PERSISTENT", "false") == "true",
+		},
+		TibiaRateLimit: getEnvInt("TIBIA_RATE_LIMIT", 5),
+		TrackedWorlds:  getEnvList("TRACKED_WORLDS"),
+		SessionRetention: SessionRetentionConfig{
+			Days:    getEnvInt("SESSION_RETENTION_DAYS", 0),
+			Archive: getEnv("SESSION_ARCHIVE", "true") == "true",
+		},
+		Database: dbConfig,
 	}
 
 	return cfg, nil
@@ -141,3 +178,22 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvList reads a comma-separated list, dropping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -70,12 +70,63 @@ func AutoMigrate() error {
 		&GuildConfig{},
 		&Player{},
 		&OnlineSession{},
+		&PlayerSnapshot{},
+		&APICacheEntry{},
+		&TrackerState{},
+		&PollingGap{},
+		&AltEdge{},
+		&PlayerCluster{},
+		&JobState{},
+		&ScanLabel{},
+		&SessionTransition{},
+		&PlayerDailyActivity{},
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	// GORM cannot declare partitioned tables, so the archive is created by
+	// hand. Monthly partitions are added as sessions are archived.
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS online_sessions_archive (
+			id                bigint NOT NULL,
+			player_id         bigint NOT NULL,
+			world             text NOT NULL DEFAULT '',
+			login_at          timestamptz NOT NULL,
+			logout_at         timestamptz,
+			login_uncertain   boolean NOT NULL DEFAULT false,
+			logout_uncertain  boolean NOT NULL DEFAULT false,
+			login_at_earliest timestamptz,
+			logout_at_latest  timestamptz,
+			created_at        timestamptz,
+			archived_at       timestamptz NOT NULL DEFAULT NOW()
+		) PARTITION BY RANGE (login_at)
+	`).Error
+	if err == nil {
+		err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_archive_player_time ON online_sessions_archive (player_id, login_at)").Error
+	}
+	if err == nil {
+		err = DB.Exec("CREATE INDEX IF NOT EXISTS idx_archive_logout ON online_sessions_archive (logout_at)").Error
+	}
+	if err == nil {
+		err = DB.Exec(`
+			CREATE OR REPLACE VIEW player_sessions AS
+			SELECT ` + SessionColumns + ` FROM online_sessions
+			UNION ALL
+			SELECT ` + SessionColumns + ` FROM online_sessions_archive
+		`).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create session archive: %w", err)
+	}
+
+	// Transitions outlive their sessions once those are rolled up, so they
+	// no longer reference online_sessions.
+	if err := DB.Exec("ALTER TABLE session_transitions DROP CONSTRAINT IF EXISTS fk_session_transitions_session").Error; err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
 	logger.Success("Database migrations completed")
 	return nil
 }
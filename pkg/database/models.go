@@ -14,6 +14,7 @@ type List struct {
 	Type           string `gorm:"not null"`
 	GuildID        string `gorm:"not null"`
 	NotifyEveryone bool   `gorm:"default:false"`
+	MinLevelDelta  int    `gorm:"default:1"`
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 }
@@ -23,14 +24,14 @@ func (List) TableName() string {
 }
 
 type ListItem struct {
-	ID        uint            `gorm:"primaryKey"`
-	ListID    uint            `gorm:"not null;index"`
-	ChannelID string          `gorm:"not null;index"`
-	Name      string          `gorm:"not null"`
-	Metadata  datatypes.JSON  `gorm:"type:jsonb;default:'{}'"`
+	ID        uint           `gorm:"primaryKey"`
+	ListID    uint           `gorm:"not null;index"`
+	ChannelID string         `gorm:"not null;index"`
+	Name      string         `gorm:"not null"`
+	Metadata  datatypes.JSON `gorm:"type:jsonb;default:'{}'"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	List      List            `gorm:"foreignKey:ListID;constraint:OnDelete:CASCADE"`
+	List      List `gorm:"foreignKey:ListID;constraint:OnDelete:CASCADE"`
 }
 
 func (ListItem) TableName() string {
@@ -41,8 +42,17 @@ type GuildConfig struct {
 	ID              uint   `gorm:"primaryKey"`
 	GuildID         string `gorm:"uniqueIndex;not null"`
 	ListsCategoryID string `gorm:""`
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	// Scan defaults for /scan in this guild. Zero values fall back to the
+	// built-in defaults.
+	ScanWindowSeconds int     `gorm:"default:0"`
+	ScanMaxResults    int     `gorm:"default:0"`
+	ScanMinLevel      int     `gorm:"default:0"`
+	ScanLookbackDays  int     `gorm:"default:0"`
+	ScanVeryHighScore float64 `gorm:"default:0"`
+	ScanHighScore     float64 `gorm:"default:0"`
+	ScanMediumScore   float64 `gorm:"default:0"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
 }
 
 func (GuildConfig) TableName() string {
@@ -52,6 +62,7 @@ func (GuildConfig) TableName() string {
 type Player struct {
 	ID        uint   `gorm:"primaryKey"`
 	Name      string `gorm:"uniqueIndex;not null"`
+	World     string `gorm:"index;not null;default:''"`
 	Level     int    `gorm:""`
 	Vocation  string `gorm:""`
 	Country   string `gorm:""`
@@ -63,14 +74,195 @@ func (Player) TableName() string {
 	return "players"
 }
 
+// PlayerSnapshot records a player's tracked values each time one of them
+// changes, so level and vocation history survive later updates.
+type PlayerSnapshot struct {
+	ID         uint      `gorm:"primaryKey"`
+	PlayerID   uint      `gorm:"index:idx_snapshot_player_time;not null"`
+	World      string    `gorm:"not null;default:''"`
+	Level      int       `gorm:""`
+	Vocation   string    `gorm:""`
+	Country    string    `gorm:""`
+	RecordedAt time.Time `gorm:"index:idx_snapshot_player_time;not null"`
+}
+
+func (PlayerSnapshot) TableName() string {
+	return "player_snapshots"
+}
+
 type OnlineSession struct {
-	ID        uint       `gorm:"primaryKey"`
-	PlayerID  uint       `gorm:"index:idx_player_time,idx_time_range;not null"`
-	LoginAt   time.Time  `gorm:"index:idx_player_time,idx_time_range;not null"`
-	LogoutAt  *time.Time `gorm:"index:idx_time_range"`
-	CreatedAt time.Time
+	ID       uint       `gorm:"primaryKey"`
+	PlayerID uint       `gorm:"index:idx_player_time,idx_time_range;not null"`
+	World    string     `gorm:"index;index:idx_session_world_login,priority:1;not null;default:''"`
+	LoginAt  time.Time  `gorm:"index:idx_player_time,idx_time_range;index:idx_session_world_login,priority:2;not null"`
+	LogoutAt *time.Time `gorm:"index:idx_time_range;index:idx_session_logout"`
+	// LoginUncertain and LogoutUncertain mark transitions the tracker only
+	// noticed after a gap in polling, so the recorded time is an estimate.
+	LoginUncertain  bool `gorm:"not null;default:false"`
+	LogoutUncertain bool `gorm:"not null;default:false"`
+	// LoginAtEarliest and LogoutAtLatest bound uncertain transitions: the
+	// login happened between LoginAtEarliest and LoginAt, the logout between
+	// LogoutAt and LogoutAtLatest.
+	LoginAtEarliest *time.Time
+	LogoutAtLatest  *time.Time
+	CreatedAt       time.Time
 }
 
 func (OnlineSession) TableName() string {
 	return "online_sessions"
 }
+
+// SessionColumns are the online_sessions columns kept when a session is
+// moved to online_sessions_archive. The player_sessions view reads them from
+// both tables, for queries that need every session regardless of age.
+const SessionColumns = "id, player_id, world, login_at, logout_at, login_uncertain, logout_uncertain, " +
+	"login_at_earliest, logout_at_latest, created_at"
+
+// TransitionBucketSeconds is the width of the time buckets in which session
+// transitions are indexed.
+const TransitionBucketSeconds = 60
+
+// SessionTransition is one login or logout of an online session, indexed by
+// world and time bucket so that finding the transitions near another one is
+// a range lookup instead of a comparison of every pair of sessions.
+// Transitions are kept when their session is rolled up, since they are the
+// evidence scans and the alt graph are built from.
+type SessionTransition struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID uint   `gorm:"index;not null"`
+	PlayerID  uint   `gorm:"index:idx_transition_player_time;not null"`
+	World     string `gorm:"index:idx_transition_lookup,priority:1;not null;default:''"`
+	// Login is true for a login and false for a logout.
+	Login bool `gorm:"index:idx_transition_lookup,priority:2;not null"`
+	// Bucket is At in Unix time divided by TransitionBucketSeconds.
+	Bucket    int64     `gorm:"index:idx_transition_lookup,priority:3;not null"`
+	At        time.Time `gorm:"index:idx_transition_player_time;index;not null"`
+	Uncertain bool      `gorm:"not null;default:false"`
+}
+
+func (SessionTransition) TableName() string {
+	return "session_transitions"
+}
+
+// PlayerDailyActivity is the daily rollup of a player's online sessions,
+// kept after the raw sessions pass the retention age. Day is a UTC date and
+// sessions spanning midnight are split between the days they cover.
+type PlayerDailyActivity struct {
+	PlayerID       uint      `gorm:"primaryKey;autoIncrement:false"`
+	Day            time.Time `gorm:"primaryKey;type:date"`
+	World          string    `gorm:"not null;default:''"`
+	OnlineMinutes  float64   `gorm:"not null;default:0"`
+	LongestMinutes float64   `gorm:"not null;default:0"`
+	// Sessions counts the sessions that started on Day.
+	Sessions  int `gorm:"not null;default:0"`
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+func (PlayerDailyActivity) TableName() string {
+	return "player_daily_activities"
+}
+
+type APICacheEntry struct {
+	Key          string `gorm:"primaryKey"`
+	Body         []byte `gorm:"not null"`
+	ETag         string `gorm:""`
+	LastModified string `gorm:""`
+	ExpiresAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (APICacheEntry) TableName() string {
+	return "api_cache_entries"
+}
+
+// PollingGap is a period in which the online tracker could not poll a world,
+// from the last successful poll to the first one after it.
+type PollingGap struct {
+	ID        uint      `gorm:"primaryKey"`
+	World     string    `gorm:"index:idx_gap_world_time;not null;default:''"`
+	StartedAt time.Time `gorm:"index:idx_gap_world_time;not null"`
+	EndedAt   time.Time `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+func (PollingGap) TableName() string {
+	return "polling_gaps"
+}
+
+// TrackerState remembers when each world was last polled, so a restarted
+// tracker can tell a short restart from a real gap.
+type TrackerState struct {
+	World      string `gorm:"primaryKey"`
+	LastPollAt time.Time
+	UpdatedAt  time.Time
+}
+
+func (TrackerState) TableName() string {
+	return "tracker_states"
+}
+
+// AltEdge accumulates the evidence that two players on the same world are
+// played by the same person. PlayerA is always the lower player ID.
+type AltEdge struct {
+	PlayerA uint `gorm:"primaryKey;autoIncrement:false"`
+	PlayerB uint `gorm:"primaryKey;autoIncrement:false;index"`
+	// Transitions counts logouts of one player followed or preceded by a
+	// login of the other within the graph window.
+	Transitions int `gorm:"not null;default:0"`
+	// Overlaps counts pairs of sessions in which both players were online at
+	// the same time, which rules the pair out.
+	Overlaps  int     `gorm:"not null;default:0"`
+	Score     float64 `gorm:"index;not null;default:0"`
+	CreatedAt time.Time
+	// UpdatedAt is indexed for rescoring the edges touched by a run and
+	// pruning stale ones.
+	UpdatedAt time.Time `gorm:"index"`
+}
+
+func (AltEdge) TableName() string {
+	return "alt_edges"
+}
+
+// PlayerCluster assigns a player to a group of likely alts. ClusterID is the
+// lowest player ID in the group.
+type PlayerCluster struct {
+	PlayerID  uint `gorm:"primaryKey;autoIncrement:false"`
+	ClusterID uint `gorm:"index;not null"`
+	UpdatedAt time.Time
+}
+
+func (PlayerCluster) TableName() string {
+	return "player_clusters"
+}
+
+// JobState stores how far an incremental job has processed its input.
+type JobState struct {
+	Name      string `gorm:"primaryKey"`
+	Cursor    time.Time
+	UpdatedAt time.Time
+}
+
+func (JobState) TableName() string {
+	return "job_states"
+}
+
+// ScanLabel records a moderator's verdict on a scan result. PlayerA is always
+// the lower player ID, so a verdict applies whichever of the two is scanned.
+type ScanLabel struct {
+	ID      uint   `gorm:"primaryKey"`
+	GuildID string `gorm:"uniqueIndex:idx_scan_label_pair;not null"`
+	PlayerA uint   `gorm:"uniqueIndex:idx_scan_label_pair;not null"`
+	PlayerB uint   `gorm:"uniqueIndex:idx_scan_label_pair;index;not null"`
+	Label   string `gorm:"not null"`
+	// Score is the scan score the pair had when it was labeled, used to tune
+	// the guild's confidence thresholds.
+	Score     float64 `gorm:"not null;default:0"`
+	LabeledBy string  `gorm:""`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (ScanLabel) TableName() string {
+	return "scan_labels"
+}
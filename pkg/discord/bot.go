@@ -2,23 +2,31 @@ package discord
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/config"
 	"github.com/ethaan/discord-api/pkg/jobs"
 	"github.com/ethaan/discord-api/pkg/logger"
 	"github.com/ethaan/discord-api/pkg/services"
+	"github.com/ethaan/discord-api/pkg/tibia"
 	"github.com/ethaan/discord-api/pkg/workers"
 )
 
+// tibiaClient is shared by the command handlers, workers and jobs so they
+// all use the same response cache.
+var tibiaClient *tibia.Client
+
 type Bot struct {
 	session       *discordgo.Session
 	commands      []*Command
+	components    []*Component
 	guildID       string
 	workerManager *workers.Manager
 	jobsManager   *jobs.Manager
 }
 
-func New(token, guildID, tibiaAPIURL string) (*Bot, error) {
+func New(token, guildID string, client *tibia.Client, trackedWorlds []string, retention config.SessionRetentionConfig) (*Bot, error) {
 	if token == "" {
 		return nil, fmt.Errorf("discord bot token is required")
 	}
@@ -30,12 +38,14 @@ func New(token, guildID, tibiaAPIURL string) (*Bot, error) {
 
 	session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMembers
 
+	tibiaClient = client
+
 	bot := &Bot{
 		session:       session,
 		commands:      make([]*Command, 0),
 		guildID:       guildID,
-		workerManager: workers.NewManager(session, tibiaAPIURL),
-		jobsManager:   jobs.NewManager(session, tibiaAPIURL),
+		workerManager: workers.NewManager(session, client, trackedWorlds),
+		jobsManager:   jobs.NewManager(session, client, retention),
 	}
 
 	return bot, nil
@@ -85,6 +95,29 @@ func (b *Bot) handleInteractionCreate(s *discordgo.Session, i *discordgo.Interac
 		b.handleCommand(s, i)
 	case discordgo.InteractionApplicationCommandAutocomplete:
 		b.handleAutocomplete(s, i)
+	case discordgo.InteractionMessageComponent:
+		b.handleComponent(s, i)
+	}
+}
+
+func (b *Bot) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	prefix, _, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+
+	for _, component := range b.components {
+		if component.Prefix == prefix {
+			if err := component.Handler(s, i); err != nil {
+				logger.Error("Error handling component %s: %v", component.Prefix, err)
+
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: fmt.Sprintf("Error handling action: %v", err),
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+			}
+			return
+		}
 	}
 }
 
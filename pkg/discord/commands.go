@@ -18,10 +18,22 @@ type Command struct {
 	AutocompleteHandler AutocompleteHandler
 }
 
+// Component handles clicks on message components whose custom ID starts
+// with Prefix followed by a colon. The rest of the custom ID carries the
+// component's arguments.
+type Component struct {
+	Prefix  string
+	Handler CommandHandler
+}
+
 func (b *Bot) RegisterCommand(cmd *Command) {
 	b.commands = append(b.commands, cmd)
 }
 
+func (b *Bot) RegisterComponent(component *Component) {
+	b.components = append(b.components, component)
+}
+
 func (b *Bot) registerCommands() error {
 	logger.Info("Registering %d commands...", len(b.commands))
 
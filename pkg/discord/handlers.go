@@ -1,13 +1,14 @@
 package discord
 
 import (
+	"bytes"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/ethaan/discord-api/pkg/ascii"
+	"github.com/ethaan/discord-api/pkg/charts"
 	"github.com/ethaan/discord-api/pkg/database"
 	"github.com/ethaan/discord-api/pkg/logger"
 	"github.com/ethaan/discord-api/pkg/repositories"
@@ -21,6 +22,25 @@ var validListTypes = []string{
 	"powergames-stats",
 	"powergamer-stats-historical",
 	"scanner",
+	"exp-lock",
+	"level-change",
+	"death-alerts",
+	"guild-change",
+	"presence",
+}
+
+// characterListTypes are the list types whose items are added with /add and
+// /add-by-guild.
+var characterListTypes = map[string]bool{
+	"premium-alerts":              true,
+	"residence-change":            true,
+	"powergames-stats":            true,
+	"powergamer-stats-historical": true,
+	"level-change":                true,
+	"death-alerts":                true,
+	"guild-change":                true,
+	"presence":                    true,
+	"scanner":                     true,
 }
 
 const errNotMonitoringList = "❌ This channel is not a monitoring list. Use this command in a list channel."
@@ -206,7 +226,7 @@ func handleAdd(s *discordgo.Session, i *discordgo.InteractionCreate) error {
 		})
 	}
 
-	if list.Type != "premium-alerts" && list.Type != "residence-change" && list.Type != "powergames-stats" && list.Type != "powergamer-stats-historical" {
+	if !characterListTypes[list.Type] {
 		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -280,7 +300,7 @@ func handleAddByGuild(s *discordgo.Session, i *discordgo.InteractionCreate) erro
 		})
 	}
 
-	if list.Type != "premium-alerts" && list.Type != "residence-change" && list.Type != "powergames-stats" && list.Type != "powergamer-stats-historical" {
+	if !characterListTypes[list.Type] {
 		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -308,12 +328,6 @@ func handleAddByGuild(s *discordgo.Session, i *discordgo.InteractionCreate) erro
 		return err
 	}
 
-	tibiaAPIURL := os.Getenv("TIBIA_API_URL")
-	if tibiaAPIURL == "" {
-		tibiaAPIURL = "http://localhost:8080"
-	}
-
-	tibiaClient := tibia.NewClient(tibiaAPIURL)
 	guild, err := tibiaClient.GetGuildMembers(guildID)
 	if err != nil {
 		content := fmt.Sprintf("❌ Failed to fetch guild members: %v", err)
@@ -496,6 +510,8 @@ func handleList(s *discordgo.Session, i *discordgo.InteractionCreate) error {
 		if list.Type == "powergames-stats" || list.Type == "powergamer-stats-historical" {
 			emptyMessage = "📋 This list is empty. Use `/add` to add characters to track.\n\n" +
 				"📊 Stats will be posted automatically for tracked characters."
+		} else if list.Type == "exp-lock" {
+			emptyMessage = "📋 This list is empty. Use `/add-exp-lock` to add characters with an exp limit."
 		} else {
 			emptyMessage = "📋 This list is empty. Use `/add` to add characters."
 		}
@@ -531,6 +547,63 @@ func handleList(s *discordgo.Session, i *discordgo.InteractionCreate) error {
 			description += fmt.Sprintf("**%s**: %s\n", item.Name, residence)
 		case "powergames-stats", "powergamer-stats-historical":
 			description += fmt.Sprintf("• **%s**\n", item.Name)
+		case "level-change":
+			level := "⏳ Pending"
+			if currentLevel, ok := item.Metadata["level"].(float64); ok {
+				level = fmt.Sprintf("Level %d", int(currentLevel))
+			}
+			description += fmt.Sprintf("**%s**: %s\n", item.Name, level)
+		case "death-alerts":
+			lastDeath := "⏳ Pending"
+			if lastDeathAt, ok := item.Metadata["last_death_at"].(string); ok {
+				lastDeath = "No deaths recorded"
+				if at, err := time.Parse(time.RFC3339, lastDeathAt); err == nil {
+					lastDeath = fmt.Sprintf("Last death <t:%d:R>", at.Unix())
+				}
+			}
+			description += fmt.Sprintf("**%s**: %s\n", item.Name, lastDeath)
+		case "guild-change":
+			if _, isGuild := item.Metadata["watch_guild_id"]; isGuild {
+				members := "⏳ Pending"
+				if current, ok := item.Metadata["members"].(map[string]interface{}); ok {
+					members = fmt.Sprintf("%d members", len(current))
+				}
+				description += fmt.Sprintf("🏰 **%s**: %s\n", item.Name, members)
+				continue
+			}
+			guild := "⏳ Pending"
+			if currentGuild, ok := item.Metadata["guild"].(string); ok {
+				guild = "No guild"
+				if currentGuild != "" {
+					guild = currentGuild
+					if rank, ok := item.Metadata["guild_rank"].(string); ok && rank != "" {
+						guild = fmt.Sprintf("%s (%s)", currentGuild, rank)
+					}
+				}
+			}
+			description += fmt.Sprintf("**%s**: %s\n", item.Name, guild)
+		case "exp-lock":
+			maxExp, _ := item.Metadata["max_exp"].(float64)
+			period, _ := item.Metadata["period"].(string)
+			gained := "⏳ Pending"
+			if periodExp, ok := item.Metadata["period_exp"].(float64); ok {
+				gained = tibia.FormatTibiaNumber(int(periodExp))
+			}
+			status := ""
+			if overLimit, ok := item.Metadata["over_limit"].(bool); ok && overLimit {
+				status = " ⚠️"
+			}
+			description += fmt.Sprintf("**%s**: %s / %s (%s)%s\n",
+				item.Name, gained, tibia.FormatTibiaNumber(int(maxExp)), period, status)
+		case "scanner":
+			scanned := "⏳ Pending"
+			if lastScannedAt, ok := item.Metadata["last_scanned_at"].(string); ok {
+				if at, err := time.Parse(time.RFC3339, lastScannedAt); err == nil {
+					candidates, _ := item.Metadata["candidates"].(map[string]interface{})
+					scanned = fmt.Sprintf("%d candidates, scanned <t:%d:R>", len(candidates), at.Unix())
+				}
+			}
+			description += fmt.Sprintf("**%s**: %s\n", item.Name, scanned)
 		default:
 			description += fmt.Sprintf("• **%s**\n", item.Name)
 		}
@@ -622,16 +695,122 @@ func handleAddExpLock(s *discordgo.Session, i *discordgo.InteractionCreate) erro
 	maxExp := optionMap["max_exp"].IntValue()
 	period := optionMap["period"].StringValue()
 
+	if maxExp <= 0 {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ max_exp must be greater than zero",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	_, err = listService.AddItem(services.AddItemInput{
+		ListID: list.ID,
+		Name:   name,
+		Metadata: map[string]interface{}{
+			"max_exp": maxExp,
+			"period":  period,
+		},
+	})
+
+	if err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Failed to add item: %v", err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
 	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("✅ Would add **%s** to exp-lock monitoring (Max: %d, Period: %s)",
-				name, maxExp, period),
+			Content: fmt.Sprintf("✅ Added **%s** to exp-lock monitoring (Max: %s, Period: %s)",
+				name, tibia.FormatTibiaNumber(int(maxExp)), period),
 			Flags: discordgo.MessageFlagsEphemeral,
 		},
 	})
 }
 
+func WatchGuildCommand() *Command {
+	return &Command{
+		Name:        "watch-guild",
+		Description: "Report every join, leave and rank change of a Tibia guild in this list",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "guild-id",
+				Description: "Tibia guild ID",
+				Required:    true,
+			},
+		},
+		Handler: handleWatchGuild,
+	}
+}
+
+func handleWatchGuild(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	channelID := i.ChannelID
+
+	listService := services.NewListService()
+	list, err := listService.GetListByChannelID(channelID)
+	if err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: errNotMonitoringList,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if list.Type != "guild-change" {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ This command can only be used in guild-change list channels",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	guildID := int(optionMap["guild-id"].IntValue())
+	name := fmt.Sprintf("Guild #%d", guildID)
+
+	_, err = listService.AddItem(services.AddItemInput{
+		ListID: list.ID,
+		Name:   name,
+		Metadata: map[string]interface{}{
+			"watch_guild_id": guildID,
+		},
+	})
+
+	if err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Failed to watch guild: %v", err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("✅ Watching **%s**. Recruits and departures will be posted here.", name),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
 func EnableEveryoneCommand() *Command {
 	return &Command{
 		Name:        "enable-everyone",
@@ -720,6 +899,76 @@ func handleDisableEveryone(s *discordgo.Session, i *discordgo.InteractionCreate)
 	})
 }
 
+func SetMinLevelDeltaCommand() *Command {
+	minValue := float64(1)
+	return &Command{
+		Name:        "set-min-level-delta",
+		Description: "Set the minimum level change that triggers an alert in this list",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "levels",
+				Description: "Minimum number of levels gained or lost before alerting",
+				Required:    true,
+				MinValue:    &minValue,
+			},
+		},
+		Handler: handleSetMinLevelDelta,
+	}
+}
+
+func handleSetMinLevelDelta(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	channelID := i.ChannelID
+
+	listService := services.NewListService()
+	list, err := listService.GetListByChannelID(channelID)
+	if err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: errNotMonitoringList,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if list.Type != "level-change" {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ This command can only be used in level-change list channels",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	list.MinLevelDelta = int(optionMap["levels"].IntValue())
+	err = listService.UpdateList(list)
+	if err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Failed to update list: %v", err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("✅ Level alerts will trigger on changes of %d or more levels", list.MinLevelDelta),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
 func ScanCommand() *Command {
 	return &Command{
 		Name:        "scan",
@@ -731,6 +980,55 @@ func ScanCommand() *Command {
 				Description: "Character name to scan",
 				Required:    true,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "window",
+				Description: "Max seconds between a logout and the next login",
+				Required:    false,
+				MinValue:    floatPtr(ScanMinWindowSeconds),
+				MaxValue:    ScanMaxWindowSeconds,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "from",
+				Description: "Only sessions from this date (YYYY-MM-DD)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "to",
+				Description: "Only sessions up to this date (YYYY-MM-DD)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "min_level",
+				Description: "Only candidates at or above this level",
+				Required:    false,
+				MinValue:    floatPtr(1),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "vocation",
+				Description: "Only candidates of this vocation",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "knight", Value: "knight"},
+					{Name: "paladin", Value: "paladin"},
+					{Name: "sorcerer", Value: "sorcerer"},
+					{Name: "druid", Value: "druid"},
+					{Name: "monk", Value: "monk"},
+					{Name: "none", Value: "none"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "limit",
+				Description: "Maximum number of results",
+				Required:    false,
+				MinValue:    floatPtr(1),
+				MaxValue:    ScanMaxResultsLimit,
+			},
 		},
 		Handler: handleScan,
 	}
@@ -769,6 +1067,17 @@ func handleScan(s *discordgo.Session, i *discordgo.InteractionCreate) error {
 
 	characterName := optionMap["name"].StringValue()
 
+	settings := services.GuildScanSettings(i.GuildID)
+	if err := applyScanOptions(&settings, optionMap); err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ %v", err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 	})
@@ -778,43 +1087,48 @@ func handleScan(s *discordgo.Session, i *discordgo.InteractionCreate) error {
 
 	startTime := time.Now()
 
-	sessionRepo := repositories.NewOnlineSessionRepository()
-	results, err := sessionRepo.ScanCharacter(
-		characterName,
-		ScanAdjacentWindowSeconds,
-		ScanMaxResults,
-	)
-
-	if err != nil {
-		content := fmt.Sprintf("❌ Failed to scan character: %v", err)
+	player, err := findTrackedPlayer(characterName)
+	if err != nil || player == nil {
+		content := fmt.Sprintf("❌ Character **%s** not found in database. The character may not have been tracked yet.", characterName)
 		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Content: &content,
 		})
 		return err
 	}
+	characterName = player.Name
 
-	playerRepo := repositories.NewPlayerRepository()
-	player, err := playerRepo.FindByName(characterName)
-	if err != nil || player == nil {
-		content := fmt.Sprintf("❌ Character **%s** not found in database. The character may not have been tracked yet.", characterName)
+	sessionRepo := repositories.NewOnlineSessionRepository()
+	results, err := sessionRepo.ScanCharacter(player, settings.Options)
+
+	if err != nil {
+		content := fmt.Sprintf("❌ Failed to scan character: %v", err)
 		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Content: &content,
 		})
 		return err
 	}
 
+	// Candidates are limited to the target's world, so only count those
 	var totalCharacters int64
-	err = database.DB.Model(&database.Player{}).Count(&totalCharacters).Error
-	if err != nil {
+	countQuery := database.DB.Model(&database.Player{})
+	if player.World != "" {
+		countQuery = countQuery.Where("world = ?", player.World)
+	}
+	if err := countQuery.Count(&totalCharacters).Error; err != nil {
 		totalCharacters = 0
 	}
 
+	title := fmt.Sprintf("🔍 Scan Results: %s", characterName)
+	if player.World != "" {
+		title = fmt.Sprintf("🔍 Scan Results: %s (%s)", characterName, player.World)
+	}
+
 	// Build embed with ASCII table
 	embed := &discordgo.MessageEmbed{
-		Title: fmt.Sprintf("🔍 Scan Results: %s", characterName),
+		Title: title,
 		Color: 0x5865F2,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("📊 Analyzed %d characters in %.2fs", totalCharacters, time.Since(startTime).Seconds()),
+			Text: fmt.Sprintf("📊 Analyzed %d characters in %.2fs • %s", totalCharacters, time.Since(startTime).Seconds(), describeScanSettings(settings)),
 		},
 	}
 
@@ -823,16 +1137,949 @@ func handleScan(s *discordgo.Session, i *discordgo.InteractionCreate) error {
 	} else {
 		table := ascii.BuildScanResultsTable(
 			results,
-			ScanVeryHighConfidenceThreshold,
-			ScanHighConfidenceThreshold,
-			ScanMediumConfidenceThreshold,
+			settings.VeryHighScore,
+			settings.HighScore,
+			settings.MediumScore,
 		)
-		embed.Description = fmt.Sprintf("```\n%s```", table)
+		embed.Description = fmt.Sprintf("```\n%s```Expected = transitions chance alone would produce. Score %.0f means a 1 in 10^%.0f chance of coincidence.",
+			table, settings.VeryHighScore, settings.VeryHighScore)
+	}
+
+	confirmed, err := repositories.NewScanLabelRepository().FindConfirmed(i.GuildID, player.ID)
+	if err != nil {
+		logger.Error("Error loading confirmed alts of %s: %v", characterName, err)
 	}
+	if len(confirmed) > 0 {
+		names := make([]string, len(confirmed))
+		for idx, alt := range confirmed {
+			names[idx] = fmt.Sprintf("**%s**", alt.Name)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "✅ Confirmed alts",
+			Value: strings.Join(names, ", "),
+		})
+	}
+
+	components := scanLabelButtons(player.ID, results)
 
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{embed},
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
 	})
 
 	return err
 }
+
+func ScanLabelComponent() *Component {
+	return &Component{
+		Prefix:  scanLabelPrefix,
+		Handler: handleScanLabel,
+	}
+}
+
+// handleScanLabel records a moderator's verdict from the /scan label buttons.
+func handleScanLabel(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionManageMessages == 0 {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ Only moderators can label scan results",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	var label string
+	var targetID, candidateID uint
+	var score float64
+	customID := strings.ReplaceAll(i.MessageComponentData().CustomID, ":", " ")
+	if _, err := fmt.Sscanf(customID, scanLabelPrefix+" %s %d %d %f", &label, &targetID, &candidateID, &score); err != nil {
+		return fmt.Errorf("invalid scan label button %q: %w", i.MessageComponentData().CustomID, err)
+	}
+
+	if label != repositories.ScanLabelConfirmed && label != repositories.ScanLabelFalsePositive {
+		return fmt.Errorf("unknown scan label %q", label)
+	}
+
+	playerRepo := repositories.NewPlayerRepository()
+	target, err := playerRepo.FindByID(targetID)
+	if err != nil {
+		return err
+	}
+	candidate, err := playerRepo.FindByID(candidateID)
+	if err != nil {
+		return err
+	}
+
+	err = repositories.NewScanLabelRepository().Save(i.GuildID, target.ID, candidate.ID, label, score, i.Member.User.ID)
+	if err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("✅ %s confirmed **%s** as an alt of **%s**.", i.Member.Mention(), candidate.Name, target.Name)
+	if label == repositories.ScanLabelFalsePositive {
+		content = fmt.Sprintf("🚫 %s marked **%s** as a false positive for **%s**. It will be left out of future scans.", i.Member.Mention(), candidate.Name, target.Name)
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+// playtimePeriods maps the /playtime period choices to their length.
+var playtimePeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+}
+
+// findTrackedPlayer looks up a player recorded by the online tracker,
+// ignoring case. It returns nil if the player has never been seen.
+func findTrackedPlayer(name string) (*database.Player, error) {
+	players, err := repositories.NewPlayerRepository().FindByNames([]string{name})
+	if err != nil || len(players) == 0 {
+		return nil, err
+	}
+	return &players[0], nil
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %02dm", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh %02dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+func LastSeenCommand() *Command {
+	return &Command{
+		Name:        "lastseen",
+		Description: "Show whether a character is online or when it was last seen",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Character name",
+				Required:    true,
+			},
+		},
+		Handler: handleLastSeen,
+	}
+}
+
+func handleLastSeen(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	name := i.ApplicationCommandData().Options[0].StringValue()
+
+	player, err := findTrackedPlayer(name)
+	if err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Failed to look up character: %v", err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	var latest *database.OnlineSession
+	if player != nil {
+		latest, err = repositories.NewOnlineSessionRepository().FindLatestSession(player.ID)
+		if err != nil {
+			return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("❌ Failed to look up sessions: %v", err),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+		}
+	}
+
+	if latest == nil {
+		content := fmt.Sprintf("❔ **%s** has never been seen online by the tracker.", name)
+
+		// Sessions past the retention age only survive as daily rollups
+		if player != nil {
+			lastSeen, err := repositories.NewDailyActivityRepository().LastSeen(player.ID)
+			if err != nil {
+				return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: fmt.Sprintf("❌ Failed to look up activity: %v", err),
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+			}
+			if lastSeen != nil {
+				content = fmt.Sprintf("⚫ **%s** was last seen <t:%d:R> (<t:%d:D>). Older sessions are only kept as daily totals, so the time is approximate.",
+					player.Name, lastSeen.Unix(), lastSeen.Unix())
+			}
+		}
+
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+			},
+		})
+	}
+
+	var content string
+	if latest.LogoutAt == nil {
+		content = fmt.Sprintf("🟢 **%s** is online now, for %s (since <t:%d:t>).",
+			player.Name, formatDuration(time.Since(latest.LoginAt)), latest.LoginAt.Unix())
+	} else {
+		content = fmt.Sprintf("⚫ **%s** was last seen <t:%d:R> (<t:%d:f>) after a %s session.",
+			player.Name, latest.LogoutAt.Unix(), latest.LogoutAt.Unix(), formatDuration(latest.LogoutAt.Sub(latest.LoginAt)))
+		if latest.LogoutUncertain {
+			content += "\n⚠️ The tracker missed some polls around this logout, so the time is approximate."
+		}
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func PlaytimeCommand() *Command {
+	return &Command{
+		Name:        "playtime",
+		Description: "Show how long a character has been online over a period",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Character name",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "period",
+				Description: "Period to summarize (default: week)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "day", Value: "day"},
+					{Name: "week", Value: "week"},
+					{Name: "month", Value: "month"},
+				},
+			},
+		},
+		Handler: handlePlaytime,
+	}
+}
+
+func handlePlaytime(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	name := optionMap["name"].StringValue()
+	period := "week"
+	if opt, ok := optionMap["period"]; ok {
+		period = opt.StringValue()
+	}
+
+	length, ok := playtimePeriods[period]
+	if !ok {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Unknown period %s", period),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	player, err := findTrackedPlayer(name)
+	if err != nil || player == nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Character **%s** not found in database. The character may not have been tracked yet.", name),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	to := time.Now()
+	from := to.Add(-length)
+
+	stats, err := repositories.NewOnlineSessionRepository().Playtime(player.ID, from, to)
+	if err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Failed to calculate playtime: %v", err),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	total := time.Duration(stats.TotalSeconds * float64(time.Second))
+	days := length.Hours() / 24
+
+	average := time.Duration(0)
+	if stats.Sessions > 0 {
+		average = total / time.Duration(stats.Sessions)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("⏱️ Playtime: %s", player.Name),
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Total Online",
+				Value:  formatDuration(total),
+				Inline: true,
+			},
+			{
+				Name:   "Sessions",
+				Value:  fmt.Sprintf("%d", stats.Sessions),
+				Inline: true,
+			},
+			{
+				Name:   "Sessions / Day",
+				Value:  fmt.Sprintf("%.1f", float64(stats.Sessions)/days),
+				Inline: true,
+			},
+			{
+				Name:   "Average Session",
+				Value:  formatDuration(average),
+				Inline: true,
+			},
+			{
+				Name:   "Longest Session",
+				Value:  formatDuration(time.Duration(stats.LongestSeconds * float64(time.Second))),
+				Inline: true,
+			},
+			{
+				Name:   "Days Active",
+				Value:  fmt.Sprintf("%d", stats.ActiveDays),
+				Inline: true,
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Last %s", period),
+		},
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		},
+	})
+}
+
+// activityLocation is the server time zone used for heatmap hours.
+var activityLocation = time.FixedZone("BRT", -3*60*60)
+
+// activityPeriods maps the /activity period choices to their length.
+var activityPeriods = map[string]time.Duration{
+	"week":    7 * 24 * time.Hour,
+	"month":   30 * 24 * time.Hour,
+	"quarter": 90 * 24 * time.Hour,
+}
+
+func ActivityCommand() *Command {
+	return &Command{
+		Name:        "activity",
+		Description: "Show when a character usually plays, by weekday and hour",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Character name",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "period",
+				Description: "Period to include (default: month)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "week", Value: "week"},
+					{Name: "month", Value: "month"},
+					{Name: "quarter", Value: "quarter"},
+				},
+			},
+		},
+		Handler: handleActivity,
+	}
+}
+
+func handleActivity(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	name := optionMap["name"].StringValue()
+	period := "month"
+	if opt, ok := optionMap["period"]; ok {
+		period = opt.StringValue()
+	}
+
+	length, ok := activityPeriods[period]
+	if !ok {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("❌ Unknown period %s", period),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		return err
+	}
+
+	player, err := findTrackedPlayer(name)
+	if err != nil || player == nil {
+		content := fmt.Sprintf("❌ Character **%s** not found in database. The character may not have been tracked yet.", name)
+		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+		return err
+	}
+
+	to := time.Now()
+	grid, err := repositories.NewOnlineSessionRepository().Activity(player.ID, to.Add(-length), to, activityLocation)
+	if err != nil {
+		content := fmt.Sprintf("❌ Failed to load activity: %v", err)
+		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+		return err
+	}
+
+	var total float64
+	for _, day := range grid {
+		for _, minutes := range day {
+			total += minutes
+		}
+	}
+
+	if total == 0 {
+		content := fmt.Sprintf("❔ **%s** has not been seen online in the last %s.", player.Name, period)
+		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+		return err
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🗓️ Activity: %s", player.Name),
+		Description: fmt.Sprintf("```\n%s```", ascii.BuildActivityHeatmap(grid)),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Last %s • %s online • hours in BRT", period, formatDuration(time.Duration(total*float64(time.Minute)))),
+		},
+	}
+
+	edit := &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{embed},
+	}
+
+	image, err := charts.ActivityHeatmapPNG(grid)
+	if err != nil {
+		// The ASCII grid already carries the data, so the image is optional.
+		logger.Error("Error rendering activity heatmap for %s: %v", player.Name, err)
+	} else {
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://activity.png"}
+		edit.Files = []*discordgo.File{
+			{
+				Name:        "activity.png",
+				ContentType: "image/png",
+				Reader:      bytes.NewReader(image),
+			},
+		}
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, edit)
+	return err
+}
+
+func ScanExplainCommand() *Command {
+	return &Command{
+		Name:        "scan-explain",
+		Description: "Show the evidence behind a scan result for a pair of characters",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "target",
+				Description: "Character that was scanned",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "candidate",
+				Description: "Suspected related character",
+				Required:    true,
+			},
+		},
+		Handler: handleScanExplain,
+	}
+}
+
+func handleScanExplain(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	listService := services.NewListService()
+	list, err := listService.GetListByChannelID(i.ChannelID)
+	if err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: errNotMonitoringList,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if list.Type != "scanner" {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ This command can only be used in scanner list channels",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	targetName := optionMap["target"].StringValue()
+	candidateName := optionMap["candidate"].StringValue()
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		return err
+	}
+
+	players := make([]*database.Player, 0, 2)
+	for _, name := range []string{targetName, candidateName} {
+		player, err := findTrackedPlayer(name)
+		if err != nil || player == nil {
+			content := fmt.Sprintf("❌ Character **%s** not found in database. The character may not have been tracked yet.", name)
+			_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				Content: &content,
+			})
+			return err
+		}
+		players = append(players, player)
+	}
+
+	return respondScanExplanation(s, i, players[0], players[1])
+}
+
+func respondScanExplanation(s *discordgo.Session, i *discordgo.InteractionCreate, target, candidate *database.Player) error {
+	opts := services.GuildScanSettings(i.GuildID).Options
+	window := opts.AdjacentWindowSeconds
+
+	explanation, err := repositories.NewOnlineSessionRepository().ExplainScan(target, candidate, opts)
+	if err != nil {
+		content := fmt.Sprintf("❌ Failed to explain scan: %v", err)
+		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+		return err
+	}
+
+	verdict := "✅ Qualified: never online at the same time"
+	color := 0x57F287
+	switch {
+	case !explanation.SameWorld:
+		verdict = fmt.Sprintf("🚫 Disqualified: different worlds (%s / %s)", target.World, candidate.World)
+		color = 0xED4245
+	case explanation.OverlappingSessions > 0:
+		verdict = fmt.Sprintf("🚫 Disqualified: online together in %d sessions for %s in total",
+			explanation.OverlappingSessions, formatDuration(time.Duration(explanation.OverlapSeconds*float64(time.Second))))
+		color = 0xED4245
+	}
+
+	description := fmt.Sprintf("%s\n**T** = %s, **C** = %s", verdict, target.Name, candidate.Name)
+
+	transitions := explanation.Transitions
+	if len(transitions) == 0 {
+		description += fmt.Sprintf("\n\nNo logout → login transitions within %ds.", window)
+	} else {
+		if len(transitions) > ScanExplainMaxTransitions {
+			transitions = transitions[:ScanExplainMaxTransitions]
+		}
+		description += fmt.Sprintf("\n```\n%s```", ascii.BuildScanEvidenceTable(transitions, activityLocation))
+		if hidden := len(explanation.Transitions) - len(transitions); hidden > 0 {
+			description += fmt.Sprintf("…and %d older transitions\n", hidden)
+		}
+		description += "❔ = time estimated across a polling gap, not counted"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🔎 Scan Evidence: %s ↔ %s", target.Name, candidate.Name),
+		Description: description,
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Counted Transitions",
+				Value:  fmt.Sprintf("%d", explanation.CountedTransitions),
+				Inline: true,
+			},
+			{
+				Name:   "Uncertain",
+				Value:  fmt.Sprintf("%d", len(explanation.Transitions)-explanation.CountedTransitions),
+				Inline: true,
+			},
+			{
+				Name:   "Window",
+				Value:  fmt.Sprintf("%ds", window),
+				Inline: true,
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Times in BRT",
+		},
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{embed},
+	})
+	return err
+}
+
+func ScanDefaultsCommand() *Command {
+	return &Command{
+		Name:        "scan-defaults",
+		Description: "View or change this server's default /scan settings (0 resets to built-in)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "window",
+				Description: "Max seconds between a logout and the next login",
+				Required:    false,
+				MinValue:    floatPtr(0),
+				MaxValue:    ScanMaxWindowSeconds,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "limit",
+				Description: "Maximum number of results",
+				Required:    false,
+				MinValue:    floatPtr(0),
+				MaxValue:    ScanMaxResultsLimit,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "min_level",
+				Description: "Only candidates at or above this level",
+				Required:    false,
+				MinValue:    floatPtr(0),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "lookback_days",
+				Description: "Only sessions from the last N days",
+				Required:    false,
+				MinValue:    floatPtr(0),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "very_high_score",
+				Description: "Minimum score for Very High confidence",
+				Required:    false,
+				MinValue:    floatPtr(0),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "high_score",
+				Description: "Minimum score for High confidence",
+				Required:    false,
+				MinValue:    floatPtr(0),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "medium_score",
+				Description: "Minimum score for Medium confidence",
+				Required:    false,
+				MinValue:    floatPtr(0),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "tune_from_labels",
+				Description: "Set the confidence scores from the results moderators labeled",
+				Required:    false,
+			},
+		},
+		Handler: handleScanDefaults,
+	}
+}
+
+func handleScanDefaults(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	configRepo := repositories.NewGuildConfigRepository()
+	config, err := configRepo.FindByGuildID(i.GuildID)
+	if err != nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "❌ This server has no configuration yet",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	options := i.ApplicationCommandData().Options
+
+	// Tuning goes first so explicit scores given alongside it still win.
+	tuned := ""
+	for _, opt := range options {
+		if opt.Name != "tune_from_labels" || !opt.BoolValue() {
+			continue
+		}
+
+		labels, err := repositories.NewScanLabelRepository().FindByGuild(i.GuildID)
+		if err == nil {
+			settings := services.GuildScanSettings(i.GuildID)
+			err = tuneScanThresholds(labels, &settings)
+			config.ScanVeryHighScore = settings.VeryHighScore
+			config.ScanHighScore = settings.HighScore
+			config.ScanMediumScore = settings.MediumScore
+		}
+		if err != nil {
+			return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("❌ %v", err),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+		}
+		tuned = fmt.Sprintf("\n• Confidence scores tuned from **%d** labeled results", len(labels))
+	}
+
+	for _, opt := range options {
+		switch opt.Name {
+		case "window":
+			config.ScanWindowSeconds = int(opt.IntValue())
+		case "limit":
+			config.ScanMaxResults = int(opt.IntValue())
+		case "min_level":
+			config.ScanMinLevel = int(opt.IntValue())
+		case "lookback_days":
+			config.ScanLookbackDays = int(opt.IntValue())
+		case "very_high_score":
+			config.ScanVeryHighScore = opt.FloatValue()
+		case "high_score":
+			config.ScanHighScore = opt.FloatValue()
+		case "medium_score":
+			config.ScanMediumScore = opt.FloatValue()
+		}
+	}
+
+	if len(options) > 0 {
+		if err := configRepo.Update(config); err != nil {
+			return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("❌ Failed to update scan defaults: %v", err),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+		}
+	}
+
+	settings := services.GuildScanSettings(i.GuildID)
+	lookback := "all history"
+	if config.ScanLookbackDays > 0 {
+		lookback = fmt.Sprintf("last %d days", config.ScanLookbackDays)
+	}
+
+	header := "⚙️ Current scan defaults"
+	if len(options) > 0 {
+		header = "✅ Updated scan defaults"
+	}
+
+	content := fmt.Sprintf("%s\n"+
+		"• Window: **%ds**\n"+
+		"• Results: **%d**\n"+
+		"• Minimum level: **%d**\n"+
+		"• Sessions: **%s**\n"+
+		"• Confidence scores: Very High **%.1f**, High **%.1f**, Medium **%.1f**%s",
+		header,
+		settings.Options.AdjacentWindowSeconds,
+		settings.Options.MaxResults,
+		settings.Options.MinLevel,
+		lookback,
+		settings.VeryHighScore,
+		settings.HighScore,
+		settings.MediumScore,
+		tuned,
+	)
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+const (
+	clusterMaxMembersShown = 20
+	clusterMaxEdgesShown   = 10
+)
+
+func ClusterCommand() *Command {
+	return &Command{
+		Name:        "cluster",
+		Description: "Show every character grouped with a character by the alt graph",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Character name",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "export",
+				Description: "Attach the cluster graph for visualization",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Graphviz (DOT)", Value: "dot"},
+					{Name: "JSON", Value: "json"},
+				},
+			},
+		},
+		Handler: handleCluster,
+	}
+}
+
+func handleCluster(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	name := optionMap["name"].StringValue()
+	export := ""
+	if opt, ok := optionMap["export"]; ok {
+		export = opt.StringValue()
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		return err
+	}
+
+	player, err := findTrackedPlayer(name)
+	if err != nil || player == nil {
+		content := fmt.Sprintf("❌ Character **%s** not found in database. The character may not have been tracked yet.", name)
+		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+		return err
+	}
+
+	cluster, err := repositories.NewAltEdgeRepository().FindCluster(player.ID)
+	if err != nil {
+		content := fmt.Sprintf("❌ Failed to load cluster: %v", err)
+		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+		return err
+	}
+
+	if cluster == nil {
+		content := fmt.Sprintf("❔ **%s** is not linked to any other character yet. The alt graph is updated every few minutes; use `/scan` for a full scan.", player.Name)
+		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+		return err
+	}
+
+	names := make(map[uint]string, len(cluster.Members))
+	for _, member := range cluster.Members {
+		names[member.ID] = member.Name
+	}
+
+	members := cluster.Members
+	if len(members) > clusterMaxMembersShown {
+		members = members[:clusterMaxMembersShown]
+	}
+	edges := cluster.Edges
+	if len(edges) > clusterMaxEdgesShown {
+		edges = edges[:clusterMaxEdgesShown]
+	}
+
+	description := fmt.Sprintf("```\n%s```\n**Strongest links**\n```\n%s```",
+		ascii.BuildClusterMembersTable(members),
+		ascii.BuildClusterEdgesTable(edges, names))
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🕸️ Cluster: %s", player.Name),
+		Description: description,
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d characters • %d links • use /scan-explain to inspect a link", len(cluster.Members), len(cluster.Edges)),
+		},
+	}
+
+	edit := &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{embed},
+	}
+
+	switch export {
+	case "dot":
+		edit.Files = []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("cluster-%d.dot", cluster.ID),
+				ContentType: "text/vnd.graphviz",
+				Reader:      bytes.NewReader(charts.ClusterDOT(cluster)),
+			},
+		}
+	case "json":
+		data, err := charts.ClusterJSON(cluster)
+		if err != nil {
+			logger.Error("Error exporting cluster %d: %v", cluster.ID, err)
+			break
+		}
+		edit.Files = []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("cluster-%d.json", cluster.ID),
+				ContentType: "application/json",
+				Reader:      bytes.NewReader(data),
+			},
+		}
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, edit)
+	return err
+}
@@ -1,19 +1,211 @@
 package discord
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/services"
+)
+
 const (
-	ScanAdjacentWindowSeconds = 60
+	// Bounds for the per-scan overrides.
+	ScanMinWindowSeconds = 5
+	ScanMaxWindowSeconds = 600
+	ScanMaxResultsLimit  = 50
+
+	// ScanExplainMaxTransitions caps the evidence rows shown by /scan-explain
+	// so the table fits in an embed.
+	ScanExplainMaxTransitions = 25
 
-	ScanVeryHighConfidenceThreshold = 15
-	ScanHighConfidenceThreshold     = 10
-	ScanMediumConfidenceThreshold   = 5
-	ScanLowConfidenceThreshold      = 3
+	// ScanFeedbackMaxResults is how many unlabeled results get label buttons
+	// on /scan. Discord allows five rows of components per message.
+	ScanFeedbackMaxResults = 5
 
-	ScanMaxResults = 20
+	// ScanTuneMinLabels is how many labeled results a guild needs before its
+	// confidence thresholds can be tuned from them.
+	ScanTuneMinLabels = 10
 )
 
+// Share of labeled results at or above each band's threshold that must be
+// confirmed alts when tuning thresholds from labels.
 const (
-	ConfidenceVeryHigh = "very_high"
-	ConfidenceHigh     = "high"
-	ConfidenceMedium   = "medium"
-	ConfidenceLow      = "low"
+	scanTuneVeryHighPrecision = 0.95
+	scanTuneHighPrecision     = 0.8
+	scanTuneMediumPrecision   = 0.5
 )
+
+// scanTuneMinScore is the lowest threshold tuning sets. Stored thresholds of
+// zero mean "use the default", so a tuned band never starts at zero.
+const scanTuneMinScore = 0.1
+
+// scanLabelPrefix starts the custom ID of the /scan label buttons, followed
+// by the label, target ID, candidate ID and score.
+const scanLabelPrefix = "scan-label"
+
+// scanDateLayout is the date format accepted by the /scan from and to options.
+const scanDateLayout = "2006-01-02"
+
+// applyScanOptions overrides settings with the options given to /scan.
+func applyScanOptions(settings *services.ScanSettings, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) error {
+	if opt, ok := optionMap["window"]; ok {
+		settings.Options.AdjacentWindowSeconds = int(opt.IntValue())
+	}
+	if opt, ok := optionMap["limit"]; ok {
+		settings.Options.MaxResults = int(opt.IntValue())
+	}
+	if opt, ok := optionMap["min_level"]; ok {
+		settings.Options.MinLevel = int(opt.IntValue())
+	}
+	if opt, ok := optionMap["vocation"]; ok {
+		settings.Options.Vocation = opt.StringValue()
+	}
+
+	if opt, ok := optionMap["from"]; ok {
+		from, err := time.ParseInLocation(scanDateLayout, opt.StringValue(), activityLocation)
+		if err != nil {
+			return fmt.Errorf("invalid from date %q, expected YYYY-MM-DD", opt.StringValue())
+		}
+		settings.Options.From = &from
+	}
+	if opt, ok := optionMap["to"]; ok {
+		to, err := time.ParseInLocation(scanDateLayout, opt.StringValue(), activityLocation)
+		if err != nil {
+			return fmt.Errorf("invalid to date %q, expected YYYY-MM-DD", opt.StringValue())
+		}
+		// The to date is inclusive.
+		to = to.AddDate(0, 0, 1)
+		settings.Options.To = &to
+	}
+
+	if settings.Options.From != nil && settings.Options.To != nil && !settings.Options.From.Before(*settings.Options.To) {
+		return fmt.Errorf("the from date must be before the to date")
+	}
+
+	return nil
+}
+
+// describeScanSettings summarizes the settings for the /scan footer.
+func describeScanSettings(s services.ScanSettings) string {
+	parts := []string{fmt.Sprintf("window %ds", s.Options.AdjacentWindowSeconds)}
+	if s.Options.From != nil {
+		parts = append(parts, "from "+s.Options.From.In(activityLocation).Format(scanDateLayout))
+	}
+	if s.Options.To != nil {
+		parts = append(parts, "to "+s.Options.To.AddDate(0, 0, -1).In(activityLocation).Format(scanDateLayout))
+	}
+	if s.Options.MinLevel > 0 {
+		parts = append(parts, fmt.Sprintf("level %d+", s.Options.MinLevel))
+	}
+	if s.Options.Vocation != "" {
+		parts = append(parts, s.Options.Vocation)
+	}
+	return strings.Join(parts, " • ")
+}
+
+// scanLabelButtons returns one row of label buttons per unlabeled result,
+// up to ScanFeedbackMaxResults.
+func scanLabelButtons(targetID uint, results []repositories.ScanResult) []discordgo.MessageComponent {
+	var rows []discordgo.MessageComponent
+
+	for _, result := range results {
+		if len(rows) == ScanFeedbackMaxResults {
+			break
+		}
+		if result.Label != "" {
+			continue
+		}
+
+		customID := func(label string) string {
+			return fmt.Sprintf("%s:%s:%d:%d:%.2f", scanLabelPrefix, label, targetID, result.PlayerID, result.Score)
+		}
+
+		rows = append(rows, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Alt: " + result.CharacterName,
+					Style:    discordgo.SuccessButton,
+					CustomID: customID(repositories.ScanLabelConfirmed),
+				},
+				discordgo.Button{
+					Label:    "Not alt: " + result.CharacterName,
+					Style:    discordgo.DangerButton,
+					CustomID: customID(repositories.ScanLabelFalsePositive),
+				},
+			},
+		})
+	}
+
+	return rows
+}
+
+// tuneScanThresholds sets each confidence threshold to the lowest labeled
+// score at which enough of the labeled results from there up are confirmed
+// alts. Bands the labels cannot support keep their current threshold.
+func tuneScanThresholds(labels []database.ScanLabel, settings *services.ScanSettings) error {
+	if len(labels) < ScanTuneMinLabels {
+		return fmt.Errorf("need at least %d labeled results to tune thresholds, this server has %d", ScanTuneMinLabels, len(labels))
+	}
+
+	sorted := make([]database.ScanLabel, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Score > sorted[j].Score
+	})
+
+	var confirmed, falsePositives int
+	for _, label := range sorted {
+		if label.Label == repositories.ScanLabelConfirmed {
+			confirmed++
+		} else {
+			falsePositives++
+		}
+	}
+	if confirmed == 0 || falsePositives == 0 {
+		return fmt.Errorf("need both confirmed alts and false positives to tune thresholds")
+	}
+
+	// lowest returns the lowest score whose labels from there up reach the
+	// precision, or ok=false if none does.
+	lowest := func(precision float64) (threshold float64, ok bool) {
+		hits := 0
+		for i, label := range sorted {
+			if label.Label == repositories.ScanLabelConfirmed {
+				hits++
+			}
+			// Only consider the last label of each score, so ties are
+			// evaluated together.
+			if i+1 < len(sorted) && sorted[i+1].Score == label.Score {
+				continue
+			}
+			if float64(hits)/float64(i+1) >= precision {
+				threshold, ok = label.Score, true
+			}
+		}
+		return threshold, ok
+	}
+
+	if threshold, ok := lowest(scanTuneVeryHighPrecision); ok {
+		settings.VeryHighScore = threshold
+	}
+	if threshold, ok := lowest(scanTuneHighPrecision); ok {
+		settings.HighScore = threshold
+	}
+	if threshold, ok := lowest(scanTuneMediumPrecision); ok {
+		settings.MediumScore = threshold
+	}
+
+	settings.VeryHighScore = max(settings.VeryHighScore, scanTuneMinScore)
+	settings.HighScore = max(min(settings.HighScore, settings.VeryHighScore), scanTuneMinScore)
+	settings.MediumScore = max(min(settings.MediumScore, settings.HighScore), scanTuneMinScore)
+
+	return nil
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,109 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/services"
+)
+
+func scanLabels(label string, scores ...float64) []database.ScanLabel {
+	labels := make([]database.ScanLabel, len(scores))
+	for i, score := range scores {
+		labels[i] = database.ScanLabel{Label: label, Score: score}
+	}
+	return labels
+}
+
+func TestTuneScanThresholds(t *testing.T) {
+	confirmed := repositories.ScanLabelConfirmed
+	falsePositive := repositories.ScanLabelFalsePositive
+
+	tests := []struct {
+		name                               string
+		labels                             []database.ScanLabel
+		wantErr                            bool
+		wantVeryHigh, wantHigh, wantMedium float64
+	}{
+		{
+			name:    "too few labels",
+			labels:  append(scanLabels(confirmed, 9, 8, 7), scanLabels(falsePositive, 1, 2)...),
+			wantErr: true,
+		},
+		{
+			name:    "only confirmed alts",
+			labels:  scanLabels(confirmed, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1),
+			wantErr: true,
+		},
+		{
+			name:    "only false positives",
+			labels:  scanLabels(falsePositive, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1),
+			wantErr: true,
+		},
+		{
+			// Precision from the top: 5/5 down to 6, 5/6 at 3, 5/7 at 2.5,
+			// 5/8 at 2, 5/9 at 1.5 and 5/10 at 1.
+			name:         "separated labels",
+			labels:       append(scanLabels(confirmed, 10, 9, 8, 7, 6), scanLabels(falsePositive, 3, 2.5, 2, 1.5, 1)...),
+			wantVeryHigh: 6,
+			wantHigh:     3,
+			wantMedium:   1,
+		},
+		{
+			// A false positive at the top keeps precision below 0.95
+			// everywhere, so the very high band keeps its threshold. The
+			// high band would start at 13 and is clamped under it.
+			name:         "false positive at the top",
+			labels:       append(scanLabels(confirmed, 19, 18, 17, 16, 15, 14, 13), scanLabels(falsePositive, 20, 3, 2)...),
+			wantVeryHigh: services.DefaultScanVeryHighScore,
+			wantHigh:     services.DefaultScanVeryHighScore,
+			wantMedium:   2,
+		},
+		{
+			// Labels sharing a score are evaluated together: at 5 the
+			// precision is 5/6, not 5/5.
+			name:         "tied scores",
+			labels:       append(scanLabels(confirmed, 9, 8, 7, 6, 5), scanLabels(falsePositive, 5, 3, 2, 1, 0.5)...),
+			wantVeryHigh: 6,
+			wantHigh:     5,
+			wantMedium:   0.5,
+		},
+		{
+			// Every label down to 0 keeps precision at 0.5, but a zero
+			// threshold would read back as unset.
+			name:         "zero scores",
+			labels:       append(scanLabels(confirmed, 9, 8, 7, 6, 5), scanLabels(falsePositive, 3, 2, 1, 0, 0)...),
+			wantVeryHigh: 5,
+			wantHigh:     3,
+			wantMedium:   scanTuneMinScore,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := services.ScanSettings{
+				VeryHighScore: services.DefaultScanVeryHighScore,
+				HighScore:     services.DefaultScanHighScore,
+				MediumScore:   services.DefaultScanMediumScore,
+			}
+
+			err := tuneScanThresholds(tt.labels, &settings)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if settings.VeryHighScore != tt.wantVeryHigh || settings.HighScore != tt.wantHigh || settings.MediumScore != tt.wantMedium {
+				t.Errorf("thresholds = %g/%g/%g, want %g/%g/%g",
+					settings.VeryHighScore, settings.HighScore, settings.MediumScore,
+					tt.wantVeryHigh, tt.wantHigh, tt.wantMedium)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/services"
+	"github.com/go-co-op/gocron/v2"
+)
+
+const (
+	altClusterJobName = "alt-cluster"
+
+	altClusterInterval = 15 * time.Minute
+
+	// altClusterSettleDelay keeps the job behind the online tracker, so both
+	// sides of a transition near the end of a range are already recorded.
+	altClusterSettleDelay = 5 * time.Minute
+
+	// altClusterMaxRange bounds how much session history a single statement
+	// processes, so catching up on old data happens in steps.
+	altClusterMaxRange = 24 * time.Hour
+
+	// altClusterMinScore is the edge score needed to link two players into
+	// the same cluster. Chaining links is riskier than a single scan result,
+	// so only very high confidence edges count.
+	altClusterMinScore = services.DefaultScanVeryHighScore
+
+	// Edges scoring below altEdgePruneScore that have not been updated for
+	// altEdgePruneAge are deleted, as they are almost always chance.
+	altEdgePruneScore = 1.0
+	altEdgePruneAge   = 30 * 24 * time.Hour
+)
+
+// AltClusterJob maintains the alt graph: it adds the sessions recorded since
+// its last run to the pairwise edge table and regroups players into
+// clusters, so /cluster never has to compare sessions itself.
+type AltClusterJob struct {
+	edgeRepo    *repositories.AltEdgeRepository
+	sessionRepo *repositories.OnlineSessionRepository
+	stateRepo   *repositories.JobStateRepository
+	scheduler   gocron.Scheduler
+}
+
+func NewAltClusterJob() *AltClusterJob {
+	return &AltClusterJob{
+		edgeRepo:    repositories.NewAltEdgeRepository(),
+		sessionRepo: repositories.NewOnlineSessionRepository(),
+		stateRepo:   repositories.NewJobStateRepository(),
+	}
+}
+
+func (j *AltClusterJob) Name() string {
+	return altClusterJobName
+}
+
+func (j *AltClusterJob) Run(ctx context.Context) {
+	scheduler, err := gocron.NewScheduler()
+	if err != nil {
+		logger.Error("Failed to create scheduler: %v", err)
+		return
+	}
+	j.scheduler = scheduler
+
+	_, err = scheduler.NewJob(
+		gocron.DurationJob(altClusterInterval),
+		gocron.NewTask(func() {
+			j.update(ctx)
+		}),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		gocron.WithStartAt(gocron.WithStartImmediately()),
+	)
+
+	if err != nil {
+		logger.Error("Failed to schedule job: %v", err)
+		return
+	}
+
+	scheduler.Start()
+	logger.Worker(altClusterJobName, "Scheduler started - will run every %s", altClusterInterval)
+
+	<-ctx.Done()
+
+	if err := scheduler.Shutdown(); err != nil {
+		logger.Error("Error shutting down scheduler: %v", err)
+	}
+}
+
+func (j *AltClusterJob) update(ctx context.Context) {
+	state, err := j.stateRepo.Get(altClusterJobName)
+	if err != nil {
+		logger.Worker(altClusterJobName, "Error loading job state: %v", err)
+		return
+	}
+
+	var cursor time.Time
+	if state != nil {
+		cursor = state.Cursor
+	} else {
+		first, err := j.sessionRepo.FirstLoginAt()
+		if err != nil {
+			logger.Worker(altClusterJobName, "Error finding first session: %v", err)
+			return
+		}
+		// Nothing recorded yet
+		if first.IsZero() {
+			return
+		}
+		// Ranges exclude their start, so begin just before the first login
+		cursor = first.Add(-time.Second)
+	}
+
+	target := time.Now().Add(-altClusterSettleDelay)
+	touched := 0
+
+	for cursor.Before(target) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		until := cursor.Add(altClusterMaxRange)
+		if until.After(target) {
+			until = target
+		}
+
+		count, err := j.edgeRepo.ProcessRange(cursor, until, services.DefaultScanWindowSeconds)
+		if err != nil {
+			logger.Worker(altClusterJobName, "Error processing sessions up to %s: %v", until.Format(time.RFC3339), err)
+			return
+		}
+
+		if err := j.stateRepo.Save(altClusterJobName, until); err != nil {
+			logger.Worker(altClusterJobName, "Error saving job state: %v", err)
+			return
+		}
+
+		cursor = until
+		touched += count
+	}
+
+	pruned, err := j.edgeRepo.PruneEdges(altEdgePruneScore, time.Now().Add(-altEdgePruneAge))
+	if err != nil {
+		logger.Worker(altClusterJobName, "Error pruning edges: %v", err)
+		return
+	}
+
+	clusters, err := j.edgeRepo.RebuildClusters(altClusterMinScore)
+	if err != nil {
+		logger.Worker(altClusterJobName, "Error rebuilding clusters: %v", err)
+		return
+	}
+
+	logger.Worker(altClusterJobName, "Updated %d edges, pruned %d, %d clusters", touched, pruned, clusters)
+}
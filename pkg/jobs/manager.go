@@ -5,7 +5,9 @@ import (
 	"sync"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/config"
 	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/tibia"
 )
 
 type Job interface {
@@ -19,11 +21,18 @@ type Manager struct {
 	cancel context.CancelFunc
 }
 
-func NewManager(session *discordgo.Session, tibiaAPIURL string) *Manager {
+func NewManager(session *discordgo.Session, tibiaClient *tibia.Client, retention config.SessionRetentionConfig) *Manager {
+	jobs := []Job{
+		NewPowergamesHistoricalWorker(session, tibiaClient),
+		NewAltClusterJob(),
+	}
+
+	if retention.Days > 0 {
+		jobs = append(jobs, NewSessionRetentionJob(retention))
+	}
+
 	return &Manager{
-		jobs: []Job{
-			NewPowergamesHistoricalWorker(session, tibiaAPIURL),
-		},
+		jobs: jobs,
 	}
 }
 
@@ -23,12 +23,12 @@ type PowergamesHistoricalWorker struct {
 	scheduler   gocron.Scheduler
 }
 
-func NewPowergamesHistoricalWorker(session *discordgo.Session, tibiaAPIURL string) *PowergamesHistoricalWorker {
+func NewPowergamesHistoricalWorker(session *discordgo.Session, tibiaClient *tibia.Client) *PowergamesHistoricalWorker {
 	return &PowergamesHistoricalWorker{
 		session:     session,
 		listRepo:    repositories.NewListRepository(),
 		itemRepo:    repositories.NewListItemRepository(),
-		tibiaClient: tibia.NewClient(tibiaAPIURL),
+		tibiaClient: tibiaClient,
 	}
 }
 
@@ -50,7 +50,7 @@ func (w *PowergamesHistoricalWorker) Run(ctx context.Context) {
 		gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(0, 5, 0))),
 		gocron.NewTask(func() {
 			logger.Worker("powergames-historical", "Running scheduled job at %s BRT", time.Now().In(brazilLocation).Format("15:04:05"))
-			w.postHistoricalStats()
+			w.postHistoricalStats(ctx)
 		}),
 	)
 
@@ -69,7 +69,7 @@ func (w *PowergamesHistoricalWorker) Run(ctx context.Context) {
 	}
 }
 
-func (w *PowergamesHistoricalWorker) postHistoricalStats() {
+func (w *PowergamesHistoricalWorker) postHistoricalStats(ctx context.Context) {
 	lists, err := w.listRepo.FindByType("powergamer-stats-historical")
 	if err != nil {
 		logger.Worker("powergames-historical", "Error fetching lists: %v", err)
@@ -79,14 +79,14 @@ func (w *PowergamesHistoricalWorker) postHistoricalStats() {
 	logger.Worker("powergames-historical", "Posting historical stats to %d channels", len(lists))
 
 	for _, list := range lists {
-		if err := w.postChannelStats(&list); err != nil {
+		if err := w.postChannelStats(ctx, &list); err != nil {
 			logger.Worker("powergames-historical", "Error posting to channel %s: %v", list.ChannelID, err)
 		}
 		time.Sleep(500 * time.Millisecond)
 	}
 }
 
-func (w *PowergamesHistoricalWorker) postChannelStats(list *database.List) error {
+func (w *PowergamesHistoricalWorker) postChannelStats(ctx context.Context, list *database.List) error {
 	items, err := w.itemRepo.FindByListID(list.ID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch list items: %w", err)
@@ -97,7 +97,7 @@ func (w *PowergamesHistoricalWorker) postChannelStats(list *database.List) error
 		return nil
 	}
 
-	powergamers, err := w.tibiaClient.GetPowergamers("lastday", "", false)
+	powergamers, err := w.tibiaClient.GetPowergamersContext(ctx, "lastday", "", false)
 	if err != nil {
 		return fmt.Errorf("failed to fetch powergamers: %w", err)
 	}
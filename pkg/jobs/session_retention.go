@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/config"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/go-co-op/gocron/v2"
+)
+
+const (
+	sessionRetentionJobName = "session-retention"
+
+	// sessionRetentionBatchSize is how many sessions are rolled up per
+	// transaction, so a large backlog does not hold locks for long.
+	sessionRetentionBatchSize = 10000
+)
+
+// SessionRetentionJob rolls online sessions older than the retention age
+// into daily per-player activity every night, then deletes or archives them.
+type SessionRetentionJob struct {
+	activityRepo *repositories.DailyActivityRepository
+	retention    config.SessionRetentionConfig
+	scheduler    gocron.Scheduler
+}
+
+func NewSessionRetentionJob(retention config.SessionRetentionConfig) *SessionRetentionJob {
+	return &SessionRetentionJob{
+		activityRepo: repositories.NewDailyActivityRepository(),
+		retention:    retention,
+	}
+}
+
+func (j *SessionRetentionJob) Name() string {
+	return sessionRetentionJobName
+}
+
+func (j *SessionRetentionJob) Run(ctx context.Context) {
+	brazilLocation := time.FixedZone("BRT", -3*60*60)
+
+	scheduler, err := gocron.NewScheduler(gocron.WithLocation(brazilLocation))
+	if err != nil {
+		logger.Error("Failed to create scheduler: %v", err)
+		return
+	}
+	j.scheduler = scheduler
+
+	_, err = scheduler.NewJob(
+		gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(4, 0, 0))),
+		gocron.NewTask(func() {
+			j.rollUp(ctx)
+		}),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+
+	if err != nil {
+		logger.Error("Failed to schedule job: %v", err)
+		return
+	}
+
+	scheduler.Start()
+	logger.Worker(sessionRetentionJobName, "Scheduler started - will roll up sessions older than %d days daily at 04:00 AM BRT", j.retention.Days)
+
+	<-ctx.Done()
+
+	if err := scheduler.Shutdown(); err != nil {
+		logger.Error("Error shutting down scheduler: %v", err)
+	}
+}
+
+func (j *SessionRetentionJob) rollUp(ctx context.Context) {
+	// Cut at a UTC midnight so most rolled-up days are complete.
+	cutoff := time.Now().UTC().AddDate(0, 0, -j.retention.Days).Truncate(24 * time.Hour)
+
+	var total int64
+	for ctx.Err() == nil {
+		rolled, err := j.activityRepo.RollUp(cutoff, sessionRetentionBatchSize, j.retention.Archive)
+		if err != nil {
+			logger.Worker(sessionRetentionJobName, "Error rolling up sessions: %v", err)
+			break
+		}
+		if rolled == 0 {
+			break
+		}
+		total += rolled
+	}
+
+	action := "deleted"
+	if j.retention.Archive {
+		action = "archived"
+	}
+	logger.Worker(sessionRetentionJobName, "Rolled up and %s %d sessions that ended before %s", action, total, cutoff.Format("2006-01-02"))
+}
@@ -0,0 +1,368 @@
+package repositories
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"gorm.io/gorm"
+)
+
+// altEdgeBatchSize bounds the rows read or written per statement when
+// rescoring edges and rebuilding clusters.
+const altEdgeBatchSize = 1000
+
+// AltCluster is a group of players likely owned by the same person, with
+// every edge between two of its members.
+type AltCluster struct {
+	ID      uint
+	Members []database.Player
+	Edges   []database.AltEdge
+}
+
+type AltEdgeRepository struct{}
+
+func NewAltEdgeRepository() *AltEdgeRepository {
+	return &AltEdgeRepository{}
+}
+
+// ProcessRange adds the evidence found in (since, until] to the edge table
+// and rescores the edges it touched. Each adjacent transition and each pair
+// of overlapping sessions is counted in the range holding its later event,
+// so consecutive ranges never count anything twice. It returns the number
+// of edges touched.
+func (r *AltEdgeRepository) ProcessRange(since, until time.Time, windowSeconds int) (int, error) {
+	// Truncated to what Postgres stores, so edges created in this run can be
+	// matched on created_at afterwards.
+	now := time.Now().Truncate(time.Microsecond)
+
+	var touched int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		params := map[string]interface{}{
+			"since":       since,
+			"until":       until,
+			"window":      windowSeconds,
+			"now":         now,
+			"bucket_span": (windowSeconds + database.TransitionBucketSeconds - 1) / database.TransitionBucketSeconds,
+		}
+
+		if err := addEdgeTransitions(tx, params); err != nil {
+			return err
+		}
+		if err := addEdgeOverlaps(tx, params); err != nil {
+			return err
+		}
+
+		var err error
+		touched, err = rescoreEdges(tx, now, until, windowSeconds)
+		return err
+	})
+
+	return touched, err
+}
+
+// addEdgeTransitions counts, per pair of players on the same world, the
+// logouts followed or preceded by a login of the other player within the
+// window, using the transition index. Uncertain transitions and those inside
+// polling gaps are skipped, as in ScanCharacter.
+func addEdgeTransitions(tx *gorm.DB, params map[string]interface{}) error {
+	return tx.Exec(`
+		INSERT INTO alt_edges (player_a, player_b, transitions, overlaps, score, created_at, updated_at)
+		SELECT
+			LEAST(o.player_id, i.player_id),
+			GREATEST(o.player_id, i.player_id),
+			COUNT(*), 0, 0, @now, @now
+		FROM session_transitions o
+		JOIN session_transitions i
+		  ON i.world = o.world
+		 AND i.login
+		 AND i.bucket BETWEEN o.bucket - @bucket_span AND o.bucket + @bucket_span
+		 AND i.player_id <> o.player_id
+		 AND ABS(EXTRACT(EPOCH FROM (i.at - o.at))) < @window
+		WHERE NOT o.login
+		  AND o.at > @since - @window * INTERVAL '1 second'
+		  AND o.at <= @until
+		  AND NOT o.uncertain
+		  AND NOT i.uncertain
+		  AND GREATEST(o.at, i.at) > @since
+		  AND GREATEST(o.at, i.at) <= @until
+		  AND NOT EXISTS (
+			SELECT 1 FROM polling_gaps g
+			WHERE g.world = o.world
+			  AND (o.at BETWEEN g.started_at AND g.ended_at
+			    OR i.at BETWEEN g.started_at AND g.ended_at)
+		  )
+		GROUP BY 1, 2
+		ON CONFLICT (player_a, player_b) DO UPDATE
+		SET transitions = alt_edges.transitions + EXCLUDED.transitions,
+		    updated_at = EXCLUDED.updated_at
+	`, params).Error
+}
+
+// addEdgeOverlaps counts the sessions in which both players of an edge were
+// online at once. Overlaps are only tracked for pairs that have an edge:
+// existing edges look at sessions closed in the range, while edges created
+// in this run are checked against their whole history up to until,
+// archived sessions included.
+func addEdgeOverlaps(tx *gorm.DB, params map[string]interface{}) error {
+	return tx.Exec(`
+		WITH recent AS (
+			SELECT id, player_id, login_at, logout_at
+			FROM player_sessions
+			WHERE logout_at > @since AND logout_at <= @until
+		),
+		counts AS (
+			-- Counted once, by whichever of the two sessions closed later
+			SELECT e.player_a, e.player_b, COUNT(*) AS overlaps
+			FROM recent r
+			JOIN alt_edges e
+			  ON (e.player_a = r.player_id OR e.player_b = r.player_id)
+			 AND e.created_at < @now
+			JOIN player_sessions s
+			  ON s.player_id = CASE WHEN e.player_a = r.player_id THEN e.player_b ELSE e.player_a END
+			 AND s.login_at < r.logout_at
+			 AND s.logout_at > r.login_at
+			 AND (s.logout_at < r.logout_at OR (s.logout_at = r.logout_at AND s.id < r.id))
+			GROUP BY e.player_a, e.player_b
+
+			UNION ALL
+
+			SELECT e.player_a, e.player_b, COUNT(*)
+			FROM alt_edges e
+			JOIN player_sessions sa
+			  ON sa.player_id = e.player_a
+			 AND sa.logout_at <= @until
+			JOIN player_sessions sb
+			  ON sb.player_id = e.player_b
+			 AND sb.logout_at <= @until
+			 AND sb.login_at < sa.logout_at
+			 AND sb.logout_at > sa.login_at
+			WHERE e.created_at = @now
+			GROUP BY e.player_a, e.player_b
+		)
+		UPDATE alt_edges e
+		SET overlaps = e.overlaps + c.overlaps,
+		    updated_at = @now
+		FROM counts c
+		WHERE e.player_a = c.player_a AND e.player_b = c.player_b
+	`, params).Error
+}
+
+// rescoreEdges recomputes the score of every edge updated at now, a batch
+// at a time in key order, so memory stays bounded however many edges a
+// range touches.
+//
+// Unlike ScanCharacter, which weighs each transition by the hour it happened,
+// the graph uses flat session rates: a logout of A has a login of B within
+// the window with probability rateB * 2 * window, so the expected count for
+// the pair is 2 * window * (sessionsA * rateB + sessionsB * rateA). The
+// score is the Poisson tail of the observed count, as in scoreResults.
+func rescoreEdges(tx *gorm.DB, now, until time.Time, windowSeconds int) (int, error) {
+	window := 2 * float64(windowSeconds)
+	touched := 0
+
+	var lastA, lastB uint
+	for {
+		var edges []database.AltEdge
+		err := tx.Where("updated_at = ? AND (player_a, player_b) > (?, ?)", now, lastA, lastB).
+			Order("player_a, player_b").
+			Limit(altEdgeBatchSize).
+			Find(&edges).Error
+		if err != nil {
+			return 0, err
+		}
+		if len(edges) == 0 {
+			return touched, nil
+		}
+
+		playerSet := make(map[uint]struct{})
+		for _, edge := range edges {
+			playerSet[edge.PlayerA] = struct{}{}
+			playerSet[edge.PlayerB] = struct{}{}
+		}
+		playerIDs := make([]uint, 0, len(playerSet))
+		for id := range playerSet {
+			playerIDs = append(playerIDs, id)
+		}
+
+		rates, err := sessionRates(tx, playerIDs, nil, &until)
+		if err != nil {
+			return 0, err
+		}
+
+		values := make([]string, len(edges))
+		args := make([]interface{}, 0, len(edges)*3)
+		for i, edge := range edges {
+			a, b := rates[edge.PlayerA], rates[edge.PlayerB]
+			expected := window * (a.Sessions*b.Rate + b.Sessions*a.Rate)
+			values[i] = "(?::bigint, ?::bigint, ?::double precision)"
+			args = append(args, edge.PlayerA, edge.PlayerB, poissonTailScore(edge.Transitions, expected))
+		}
+
+		err = tx.Exec(`
+			UPDATE alt_edges e
+			SET score = v.score
+			FROM (VALUES `+strings.Join(values, ", ")+`) AS v(player_a, player_b, score)
+			WHERE e.player_a = v.player_a AND e.player_b = v.player_b
+		`, args...).Error
+		if err != nil {
+			return 0, err
+		}
+
+		touched += len(edges)
+		last := edges[len(edges)-1]
+		lastA, lastB = last.PlayerA, last.PlayerB
+	}
+}
+
+// PruneEdges deletes the edges scoring below maxScore that never overlapped
+// and have not been updated since olderThan, and returns how many were
+// deleted. Most pairs only ever share a chance transition or two, so without
+// pruning the table grows with the square of the players seen. A pruned
+// pair that turns up again starts counting from zero, which only matters
+// for pairs too weak to link anyway. Edges with overlaps are kept, since
+// they stop clusters from merging.
+func (r *AltEdgeRepository) PruneEdges(maxScore float64, olderThan time.Time) (int64, error) {
+	result := database.DB.
+		Where("overlaps = 0 AND score < ? AND updated_at < ?", maxScore, olderThan).
+		Delete(&database.AltEdge{})
+	return result.RowsAffected, result.Error
+}
+
+// RebuildClusters regroups players into clusters from the edges scoring at
+// least minScore that never overlapped, and replaces the stored clusters.
+// Edges are merged strongest first, and a merge is skipped when it would put
+// two players known to have been online together in the same cluster. It
+// returns the number of clusters.
+func (r *AltEdgeRepository) RebuildClusters(minScore float64) (int, error) {
+	var links []database.AltEdge
+	err := database.DB.
+		Where("overlaps = 0 AND score >= ?", minScore).
+		Order("score DESC").
+		Find(&links).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var conflictEdges []database.AltEdge
+	if err := database.DB.Where("overlaps > 0").Find(&conflictEdges).Error; err != nil {
+		return 0, err
+	}
+
+	conflicts := make(map[uint][]uint)
+	for _, edge := range conflictEdges {
+		conflicts[edge.PlayerA] = append(conflicts[edge.PlayerA], edge.PlayerB)
+		conflicts[edge.PlayerB] = append(conflicts[edge.PlayerB], edge.PlayerA)
+	}
+
+	parent := make(map[uint]uint)
+	members := make(map[uint][]uint)
+
+	var find func(id uint) uint
+	find = func(id uint) uint {
+		p, ok := parent[id]
+		if !ok {
+			parent[id] = id
+			members[id] = []uint{id}
+			return id
+		}
+		if p != id {
+			parent[id] = find(p)
+		}
+		return parent[id]
+	}
+
+	conflicting := func(rootA, rootB uint) bool {
+		for _, member := range members[rootA] {
+			for _, other := range conflicts[member] {
+				if _, known := parent[other]; known && find(other) == rootB {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for _, link := range links {
+		rootA, rootB := find(link.PlayerA), find(link.PlayerB)
+		if rootA == rootB || conflicting(rootA, rootB) {
+			continue
+		}
+		if len(members[rootA]) < len(members[rootB]) {
+			rootA, rootB = rootB, rootA
+		}
+		parent[rootB] = rootA
+		members[rootA] = append(members[rootA], members[rootB]...)
+		delete(members, rootB)
+	}
+
+	var clusters []database.PlayerCluster
+	clusterCount := 0
+	for _, ids := range members {
+		if len(ids) < 2 {
+			continue
+		}
+		clusterCount++
+
+		clusterID := ids[0]
+		for _, id := range ids {
+			clusterID = min(clusterID, id)
+		}
+		for _, id := range ids {
+			clusters = append(clusters, database.PlayerCluster{PlayerID: id, ClusterID: clusterID})
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM player_clusters").Error; err != nil {
+			return err
+		}
+		if len(clusters) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(clusters, altEdgeBatchSize).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return clusterCount, nil
+}
+
+// FindCluster returns the cluster containing playerID, or nil if the player
+// is not in one.
+func (r *AltEdgeRepository) FindCluster(playerID uint) (*AltCluster, error) {
+	var assignments []database.PlayerCluster
+	if err := database.DB.Where("player_id = ?", playerID).Limit(1).Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	if len(assignments) == 0 {
+		return nil, nil
+	}
+
+	cluster := &AltCluster{ID: assignments[0].ClusterID}
+
+	err := database.DB.
+		Joins("JOIN player_clusters pc ON pc.player_id = players.id").
+		Where("pc.cluster_id = ?", cluster.ID).
+		Order("players.level DESC, players.name").
+		Find(&cluster.Members).Error
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs := make([]uint, len(cluster.Members))
+	for i, member := range cluster.Members {
+		memberIDs[i] = member.ID
+	}
+
+	err = database.DB.
+		Where("player_a IN ? AND player_b IN ?", memberIDs, memberIDs).
+		Order("score DESC").
+		Find(&cluster.Edges).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return cluster, nil
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/tibia"
+	"gorm.io/gorm/clause"
+)
+
+// APICacheRepository is a Postgres-backed tibia.Cache, used behind the
+// in-memory cache so responses survive restarts.
+type APICacheRepository struct{}
+
+func NewAPICacheRepository() *APICacheRepository {
+	return &APICacheRepository{}
+}
+
+func (r *APICacheRepository) Get(key string) (*tibia.CacheEntry, bool) {
+	var row database.APICacheEntry
+	if err := database.DB.Where("key = ?", key).Limit(1).Find(&row).Error; err != nil {
+		logger.Warn("Failed to read API cache entry: %v", err)
+		return nil, false
+	}
+	if row.Key == "" {
+		return nil, false
+	}
+
+	return &tibia.CacheEntry{
+		Body:         row.Body,
+		ETag:         row.ETag,
+		LastModified: row.LastModified,
+		ExpiresAt:    row.ExpiresAt,
+	}, true
+}
+
+func (r *APICacheRepository) Set(key string, entry *tibia.CacheEntry) {
+	row := database.APICacheEntry{
+		Key:          key,
+		Body:         entry.Body,
+		ETag:         entry.ETag,
+		LastModified: entry.LastModified,
+		ExpiresAt:    entry.ExpiresAt,
+	}
+
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		UpdateAll: true,
+	}).Create(&row).Error
+	if err != nil {
+		logger.Warn("Failed to write API cache entry: %v", err)
+	}
+}
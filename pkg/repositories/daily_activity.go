@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"gorm.io/gorm"
+)
+
+type DailyActivityRepository struct{}
+
+func NewDailyActivityRepository() *DailyActivityRepository {
+	return &DailyActivityRepository{}
+}
+
+// RollUp folds up to limit closed sessions that ended before cutoff into
+// daily activity, then deletes them or, with archive set, moves them to
+// online_sessions_archive. Both happen in one transaction, so a session is
+// always counted either raw or rolled up, never both. Its indexed
+// transitions are kept. It returns the number
+// of sessions rolled up; zero means there is nothing left before cutoff.
+func (r *DailyActivityRepository) RollUp(cutoff time.Time, limit int, archive bool) (int64, error) {
+	var rolled int64
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// The batch is every old session up to the limit-th one by ID, so
+		// the statements below agree on it without passing IDs around.
+		var maxID *uint
+		err := tx.Raw(`
+			SELECT MAX(id) FROM (
+				SELECT id FROM online_sessions
+				WHERE logout_at < ?
+				ORDER BY id
+				LIMIT ?
+			) batch
+		`, cutoff, limit).Scan(&maxID).Error
+		if err != nil || maxID == nil {
+			return err
+		}
+
+		params := map[string]interface{}{
+			"cutoff": cutoff,
+			"max_id": *maxID,
+		}
+
+		err = tx.Exec(`
+			INSERT INTO player_daily_activities
+				(player_id, day, world, online_minutes, longest_minutes, sessions, first_seen, last_seen)
+			SELECT
+				s.player_id,
+				(d.day AT TIME ZONE 'UTC')::date,
+				MAX(s.world),
+				SUM(EXTRACT(EPOCH FROM (LEAST(s.logout_at, d.day + INTERVAL '1 day') - GREATEST(s.login_at, d.day)))) / 60,
+				MAX(EXTRACT(EPOCH FROM (LEAST(s.logout_at, d.day + INTERVAL '1 day') - GREATEST(s.login_at, d.day)))) / 60,
+				COUNT(*) FILTER (WHERE s.login_at >= d.day),
+				MIN(GREATEST(s.login_at, d.day)),
+				MAX(LEAST(s.logout_at, d.day + INTERVAL '1 day'))
+			FROM online_sessions s
+			CROSS JOIN LATERAL generate_series(
+				date_trunc('day', s.login_at, 'UTC'),
+				date_trunc('day', s.logout_at, 'UTC'),
+				INTERVAL '1 day'
+			) AS d(day)
+			WHERE s.logout_at < @cutoff AND s.id <= @max_id
+			  -- A session ending exactly at midnight adds nothing to the next day
+			  AND (d.day < s.logout_at OR d.day = date_trunc('day', s.login_at, 'UTC'))
+			GROUP BY s.player_id, d.day
+			ON CONFLICT (player_id, day) DO UPDATE
+			SET world = EXCLUDED.world,
+			    online_minutes = player_daily_activities.online_minutes + EXCLUDED.online_minutes,
+			    longest_minutes = GREATEST(player_daily_activities.longest_minutes, EXCLUDED.longest_minutes),
+			    sessions = player_daily_activities.sessions + EXCLUDED.sessions,
+			    first_seen = LEAST(player_daily_activities.first_seen, EXCLUDED.first_seen),
+			    last_seen = GREATEST(player_daily_activities.last_seen, EXCLUDED.last_seen)
+		`, params).Error
+		if err != nil {
+			return err
+		}
+
+		var result *gorm.DB
+		if archive {
+			if err := ensureArchivePartitions(tx, params); err != nil {
+				return err
+			}
+			result = tx.Exec(`
+				WITH moved AS (
+					DELETE FROM online_sessions
+					WHERE logout_at < @cutoff AND id <= @max_id
+					RETURNING `+database.SessionColumns+`
+				)
+				INSERT INTO online_sessions_archive (`+database.SessionColumns+`)
+				SELECT `+database.SessionColumns+` FROM moved
+			`, params)
+		} else {
+			result = tx.Exec("DELETE FROM online_sessions WHERE logout_at < @cutoff AND id <= @max_id", params)
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		rolled = result.RowsAffected
+		return nil
+	})
+
+	return rolled, err
+}
+
+// ensureArchivePartitions creates the monthly archive partitions the batch
+// of sessions about to be archived falls in.
+func ensureArchivePartitions(tx *gorm.DB, params map[string]interface{}) error {
+	var months []time.Time
+	err := tx.Raw(`
+		SELECT DISTINCT date_trunc('month', login_at, 'UTC')
+		FROM online_sessions
+		WHERE logout_at < @cutoff AND id <= @max_id
+	`, params).Scan(&months).Error
+	if err != nil {
+		return err
+	}
+
+	for _, month := range months {
+		start := month.UTC()
+		end := start.AddDate(0, 1, 0)
+
+		err := tx.Exec(fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS online_sessions_archive_%s PARTITION OF online_sessions_archive FOR VALUES FROM ('%s') TO ('%s')",
+			start.Format("2006_01"), start.Format(time.RFC3339), end.Format(time.RFC3339),
+		)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LastSeen returns the end of the latest rolled-up session of a player, or
+// nil if none of its sessions has been rolled up.
+func (r *DailyActivityRepository) LastSeen(playerID uint) (*time.Time, error) {
+	var lastSeen *time.Time
+	err := database.DB.Model(&database.PlayerDailyActivity{}).
+		Select("MAX(last_seen)").
+		Where("player_id = ?", playerID).
+		Scan(&lastSeen).Error
+	return lastSeen, err
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"gorm.io/gorm/clause"
+)
+
+type JobStateRepository struct{}
+
+func NewJobStateRepository() *JobStateRepository {
+	return &JobStateRepository{}
+}
+
+// Get returns the saved state for the named job, or nil if it has never run.
+func (r *JobStateRepository) Get(name string) (*database.JobState, error) {
+	var states []database.JobState
+	if err := database.DB.Where("name = ?", name).Limit(1).Find(&states).Error; err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
+
+func (r *JobStateRepository) Save(name string, cursor time.Time) error {
+	state := database.JobState{
+		Name:   name,
+		Cursor: cursor,
+	}
+
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cursor", "updated_at"}),
+	}).Create(&state).Error
+}
@@ -38,6 +38,22 @@ func (r *ListItemRepository) FindByName(listID uint, name string) (*database.Lis
 	return &item, err
 }
 
+func (r *ListItemRepository) FindByID(id uint) (*database.ListItem, error) {
+	var item database.ListItem
+	err := r.db.Where("id = ?", id).First(&item).Error
+	return &item, err
+}
+
+// SetMetadataValue sets a single string key of an item's metadata without
+// rewriting the rest, so it cannot clobber keys written concurrently by
+// another worker.
+func (r *ListItemRepository) SetMetadataValue(id uint, key, value string) error {
+	return r.db.Model(&database.ListItem{}).
+		Where("id = ?", id).
+		Update("metadata", gorm.Expr("jsonb_set(COALESCE(metadata, '{}'::jsonb), ARRAY[?::text], to_jsonb(?::text))", key, value)).
+		Error
+}
+
 func (r *ListItemRepository) Update(item *database.ListItem) error {
 	return r.db.Save(item).Error
 }
@@ -45,3 +61,56 @@ func (r *ListItemRepository) Update(item *database.ListItem) error {
 func (r *ListItemRepository) Delete(item *database.ListItem) error {
 	return r.db.Delete(item).Error
 }
+
+// FindNamesExcept returns the distinct character names on every list whose
+// type is not in listTypes, skipping whole-guild watches.
+func (r *ListItemRepository) FindNamesExcept(listTypes []string) ([]string, error) {
+	var names []string
+	err := r.db.Model(&database.ListItem{}).
+		Joins("JOIN lists ON lists.id = list_items.list_id").
+		Where("lists.type NOT IN ? AND list_items.metadata->'watch_guild_id' IS NULL", listTypes).
+		Distinct().
+		Pluck("list_items.name", &names).Error
+	return names, err
+}
+
+// RenameAll renames every item named oldName (case-insensitively) across all
+// lists and returns the renamed items with their list. Items in lists that
+// already contain newName are removed instead, to avoid duplicates.
+func (r *ListItemRepository) RenameAll(oldName, newName string) ([]database.ListItem, error) {
+	var renamed []database.ListItem
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var items []database.ListItem
+		if err := tx.Preload("List").Where("LOWER(name) = LOWER(?)", oldName).Find(&items).Error; err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			var duplicates int64
+			err := tx.Model(&database.ListItem{}).
+				Where("list_id = ? AND LOWER(name) = LOWER(?)", item.ListID, newName).
+				Count(&duplicates).Error
+			if err != nil {
+				return err
+			}
+
+			if duplicates > 0 {
+				if err := tx.Delete(&item).Error; err != nil {
+					return err
+				}
+			} else {
+				if err := tx.Model(&item).Update("name", newName).Error; err != nil {
+					return err
+				}
+			}
+
+			item.Name = newName
+			renamed = append(renamed, item)
+		}
+
+		return nil
+	})
+
+	return renamed, err
+}
@@ -1,17 +1,83 @@
 package repositories
 
 import (
+	"sort"
 	"time"
 
 	"github.com/ethaan/discord-api/pkg/database"
+	"gorm.io/gorm"
 )
 
 type ScanResult struct {
+	PlayerID        uint
 	CharacterName   string
 	AdjacentCount   int
 	ConfidenceLevel string
+	// ExpectedCount is how many adjacent transitions chance alone would
+	// produce for this candidate, given how often it logs in and out and how
+	// busy the world is at the hours the target plays.
+	ExpectedCount float64
+	// Score is -log10 of the probability of seeing at least AdjacentCount
+	// transitions by chance, so 2 means 1 in 100 and 4 means 1 in 10,000.
+	Score float64
+	// Label is the moderator label on the pair in the scanning guild, if any.
+	Label string
 }
 
+// OpenSession is a session without a logout, joined with its player's name.
+type OpenSession struct {
+	SessionID  uint
+	PlayerID   uint
+	PlayerName string
+	LoginAt    time.Time
+}
+
+// ScanTransition is one logout of a character followed closely by a login of
+// the other character in a scanned pair.
+type ScanTransition struct {
+	// TargetFirst is true when the target logged out and the candidate
+	// logged in, false for the reverse.
+	TargetFirst bool
+	LogoutAt    time.Time
+	LoginAt     time.Time
+	GapSeconds  float64
+	// Uncertain transitions involve an estimated time and are not counted
+	// by ScanCharacter.
+	Uncertain bool
+}
+
+// ScanExplanation is the evidence behind a single ScanCharacter result.
+type ScanExplanation struct {
+	Target             database.Player
+	Candidate          database.Player
+	SameWorld          bool
+	Transitions        []ScanTransition
+	CountedTransitions int
+	// OverlappingSessions counts the candidate's sessions that overlap one
+	// of the target's, and OverlapSeconds the time they were online together.
+	OverlappingSessions int
+	OverlapSeconds      float64
+}
+
+// Qualified reports whether the candidate passes ScanCharacter's filters:
+// same world and never online at the same time as the target.
+func (e *ScanExplanation) Qualified() bool {
+	return e.SameWorld && e.OverlappingSessions == 0
+}
+
+// PlaytimeStats summarizes a player's sessions over a period. Sessions that
+// cross the period boundaries only count the time inside it.
+type PlaytimeStats struct {
+	Sessions       int
+	TotalSeconds   float64
+	LongestSeconds float64
+	ActiveDays     int
+}
+
+// ActivityGrid holds the minutes a player spent online per weekday (Monday
+// first) and hour of day.
+type ActivityGrid [7][24]float64
+
 type OnlineSessionRepository struct{}
 
 func NewOnlineSessionRepository() *OnlineSessionRepository {
@@ -27,18 +93,245 @@ func (r *OnlineSessionRepository) FindActiveSession(playerID uint) (*database.On
 	return &session, nil
 }
 
-func (r *OnlineSessionRepository) CreateSession(playerID uint, loginAt time.Time) error {
+// CreateSession opens a session. For uncertain logins, earliest is the
+// earliest time the login could have happened, or nil when unknown.
+func (r *OnlineSessionRepository) CreateSession(playerID uint, world string, loginAt time.Time, uncertain bool, earliest *time.Time) error {
 	session := database.OnlineSession{
-		PlayerID: playerID,
-		LoginAt:  loginAt,
+		PlayerID:        playerID,
+		World:           world,
+		LoginAt:         loginAt,
+		LoginUncertain:  uncertain,
+		LoginAtEarliest: earliest,
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		return indexSessions(tx, []uint{session.ID}, true)
+	})
+}
+
+// CloseSession ends a session. For uncertain logouts, latest is the latest
+// time the logout could have happened, or nil when unknown.
+func (r *OnlineSessionRepository) CloseSession(sessionID uint, logoutAt time.Time, uncertain bool, latest *time.Time) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&database.OnlineSession{}).
+			Where("id = ?", sessionID).
+			Updates(map[string]interface{}{
+				"logout_at":        logoutAt,
+				"logout_uncertain": uncertain,
+				"logout_at_latest": latest,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return indexSessions(tx, []uint{sessionID}, false)
+	})
+}
+
+// FindLatestSession returns the player's most recent session, or nil if the
+// player has never been seen online.
+func (r *OnlineSessionRepository) FindLatestSession(playerID uint) (*database.OnlineSession, error) {
+	var sessions []database.OnlineSession
+	err := database.DB.Where("player_id = ?", playerID).
+		Order("login_at DESC").
+		Limit(1).
+		Find(&sessions).Error
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return &sessions[0], nil
+}
+
+// Playtime returns the player's online time between from and to. Days whose
+// sessions were already rolled up count in full, and for them only the
+// longest part of a session within a single day is known.
+func (r *OnlineSessionRepository) Playtime(playerID uint, from, to time.Time) (*PlaytimeStats, error) {
+	query := `
+		WITH clipped AS (
+			SELECT
+				GREATEST(login_at, @from) AS started_at,
+				LEAST(COALESCE(logout_at, NOW()), @to) AS ended_at
+			FROM online_sessions
+			WHERE player_id = @player_id
+			  AND login_at < @to
+			  AND COALESCE(logout_at, NOW()) > @from
+		),
+		rolled AS (
+			SELECT day, sessions, online_minutes, longest_minutes
+			FROM player_daily_activities
+			WHERE player_id = @player_id
+			  AND day >= (CAST(@from AS timestamptz) AT TIME ZONE 'UTC')::date
+			  AND day < (CAST(@to AS timestamptz) AT TIME ZONE 'UTC')::date
+		)
+		SELECT
+			(SELECT COUNT(*) FROM clipped)
+				+ (SELECT COALESCE(SUM(sessions), 0) FROM rolled) AS sessions,
+			(SELECT COALESCE(SUM(EXTRACT(EPOCH FROM (ended_at - started_at))), 0) FROM clipped)
+				+ (SELECT COALESCE(SUM(online_minutes), 0) * 60 FROM rolled) AS total_seconds,
+			GREATEST(
+				(SELECT COALESCE(MAX(EXTRACT(EPOCH FROM (ended_at - started_at))), 0) FROM clipped),
+				(SELECT COALESCE(MAX(longest_minutes), 0) * 60 FROM rolled)
+			) AS longest_seconds,
+			(SELECT COUNT(*) FROM (
+				SELECT DATE(started_at AT TIME ZONE 'UTC') FROM clipped
+				UNION
+				SELECT day FROM rolled
+			) days) AS active_days
+	`
+
+	var stats PlaytimeStats
+	err := database.DB.Raw(query, map[string]interface{}{
+		"player_id": playerID,
+		"from":      from,
+		"to":        to,
+	}).Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Activity spreads the player's online time between from and to over an
+// ActivityGrid, using hours and weekdays in loc. Archived sessions are
+// included.
+func (r *OnlineSessionRepository) Activity(playerID uint, from, to time.Time, loc *time.Location) (*ActivityGrid, error) {
+	var sessions []database.OnlineSession
+	err := database.DB.Table("player_sessions").Where("player_id = ? AND login_at < ? AND (logout_at IS NULL OR logout_at > ?)", playerID, to, from).
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var grid ActivityGrid
+	now := time.Now()
+
+	for _, session := range sessions {
+		start := session.LoginAt
+		if start.Before(from) {
+			start = from
+		}
+		end := now
+		if session.LogoutAt != nil {
+			end = *session.LogoutAt
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		// Walk the session one clock hour at a time.
+		for cursor := start.In(loc); cursor.Before(end); {
+			next := cursor.Truncate(time.Hour).Add(time.Hour)
+			if next.After(end) {
+				next = end
+			}
+			day := (int(cursor.Weekday()) + 6) % 7
+			grid[day][cursor.Hour()] += next.Sub(cursor).Minutes()
+			cursor = next.In(loc)
+		}
+	}
+
+	return &grid, nil
+}
+
+// FindActiveSessions returns the open sessions of the given players in
+// world, keyed by player ID.
+func (r *OnlineSessionRepository) FindActiveSessions(world string, playerIDs []uint) (map[uint]database.OnlineSession, error) {
+	active := make(map[uint]database.OnlineSession)
+	if len(playerIDs) == 0 {
+		return active, nil
+	}
+
+	var sessions []database.OnlineSession
+	err := database.DB.Where("world = ? AND player_id IN ? AND logout_at IS NULL", world, playerIDs).Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		active[session.PlayerID] = session
+	}
+	return active, nil
+}
+
+// ApplyTransitions closes and opens sessions for one poll in a single
+// transaction. Every closed session gets the same logout values.
+func (r *OnlineSessionRepository) ApplyTransitions(opened []database.OnlineSession, closedIDs []uint, logoutAt time.Time, uncertain bool, latest *time.Time) error {
+	if len(opened) == 0 && len(closedIDs) == 0 {
+		return nil
 	}
-	return database.DB.Create(&session).Error
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(closedIDs) > 0 {
+			err := tx.Model(&database.OnlineSession{}).
+				Where("id IN ?", closedIDs).
+				Updates(map[string]interface{}{
+					"logout_at":        logoutAt,
+					"logout_uncertain": uncertain,
+					"logout_at_latest": latest,
+				}).Error
+			if err != nil {
+				return err
+			}
+			if err := indexSessions(tx, closedIDs, false); err != nil {
+				return err
+			}
+		}
+
+		if len(opened) > 0 {
+			if err := tx.CreateInBatches(&opened, upsertBatchSize).Error; err != nil {
+				return err
+			}
+
+			openedIDs := make([]uint, len(opened))
+			for i, session := range opened {
+				openedIDs[i] = session.ID
+			}
+			if err := indexSessions(tx, openedIDs, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
-func (r *OnlineSessionRepository) CloseSession(sessionID uint, logoutAt time.Time) error {
-	return database.DB.Model(&database.OnlineSession{}).
-		Where("id = ?", sessionID).
-		Update("logout_at", logoutAt).Error
+// FindOpenSessions returns every session in world that has not been closed.
+func (r *OnlineSessionRepository) FindOpenSessions(world string) ([]OpenSession, error) {
+	var sessions []OpenSession
+	err := database.DB.Table("online_sessions s").
+		Select("s.id AS session_id, s.player_id, p.name AS player_name, s.login_at").
+		Joins("JOIN players p ON p.id = s.player_id").
+		Where("s.world = ? AND s.logout_at IS NULL", world).
+		Scan(&sessions).Error
+	return sessions, err
+}
+
+// CloseLegacySessions closes the sessions left open by a tracker that ran
+// before sessions recorded their world, and returns how many were closed.
+// The logout is uncertain: no earlier than lastSeenAt, when that tracker
+// last polled, and no later than latest.
+func (r *OnlineSessionRepository) CloseLegacySessions(lastSeenAt, latest time.Time) (int, error) {
+	var closedIDs []uint
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Raw(`
+			UPDATE online_sessions
+			SET logout_at = GREATEST(login_at, @last_seen_at),
+			    logout_uncertain = TRUE,
+			    logout_at_latest = @latest
+			WHERE world = '' AND logout_at IS NULL
+			RETURNING id
+		`, map[string]interface{}{
+			"last_seen_at": lastSeenAt,
+			"latest":       latest,
+		}).Scan(&closedIDs).Error
+		if err != nil {
+			return err
+		}
+		return indexSessions(tx, closedIDs, false)
+	})
+	return len(closedIDs), err
 }
 
 func (r *OnlineSessionRepository) FindByPlayerID(playerID uint) ([]database.OnlineSession, error) {
@@ -49,63 +342,263 @@ func (r *OnlineSessionRepository) FindByPlayerID(playerID uint) ([]database.Onli
 	return sessions, err
 }
 
-func (r *OnlineSessionRepository) ScanCharacter(characterName string, adjacentWindowSeconds int, maxResults int) ([]ScanResult, error) {
+// FirstLoginAt returns the earliest recorded login, or the zero time if no
+// session has been recorded.
+func (r *OnlineSessionRepository) FirstLoginAt() (time.Time, error) {
+	var first *time.Time
+	if err := database.DB.Model(&database.OnlineSession{}).Select("MIN(login_at)").Scan(&first).Error; err != nil {
+		return time.Time{}, err
+	}
+	if first == nil {
+		return time.Time{}, nil
+	}
+	return *first, nil
+}
+
+// ScanOptions tune a single ScanCharacter call. Zero values disable the
+// optional filters.
+type ScanOptions struct {
+	AdjacentWindowSeconds int
+	MaxResults            int
+	// From and To limit the evidence to the target's transitions in
+	// [From, To), and the overlap check to its sessions starting then.
+	From *time.Time
+	To   *time.Time
+	// MinLevel and Vocation filter candidates by their last known level
+	// and vocation. Vocation matches partially, so "knight" also matches
+	// "Elite Knight".
+	MinLevel int
+	Vocation string
+	// GuildID applies that guild's scan labels: pairs marked as false
+	// positives are left out and confirmed pairs are flagged.
+	GuildID string
+}
+
+// ScanCharacter finds the characters whose logins and logouts sit next to
+// the target's. Adjacent transitions are looked up in the transition index,
+// a few time buckets around each of the target's transitions, so the cost
+// grows with the target's history and how busy its world is rather than
+// with the size of online_sessions. The overlap check also reads archived
+// sessions, so rolled-up history still rules candidates out.
+func (r *OnlineSessionRepository) ScanCharacter(target *database.Player, opts ScanOptions) ([]ScanResult, error) {
 	query := `
-		WITH target_sessions AS (
-			SELECT s.login_at, s.logout_at
-			FROM online_sessions s
-			JOIN players p ON s.player_id = p.id
-			WHERE p.name = ?
+		WITH target_player AS (
+			SELECT id, world
+			FROM players
+			WHERE id = @target
 		),
-		candidate_characters AS (
-			SELECT DISTINCT p2.id, p2.name
-			FROM players p2
-			WHERE p2.name != ?
-			  -- Never online at same time (all pairs must not overlap)
+		target_transitions AS (
+			SELECT t.world, t.login, t.bucket, t.at
+			FROM session_transitions t
+			JOIN target_player tp ON t.player_id = tp.id
+			WHERE NOT t.uncertain
+			  AND (CAST(@from AS timestamptz) IS NULL OR t.at >= @from)
+			  AND (CAST(@to AS timestamptz) IS NULL OR t.at < @to)
+		),
+		adjacent AS (
+			-- Transitions of the opposite kind by other characters within
+			-- the window. Transitions recorded across a polling gap, or
+			-- that fall inside one, have estimated times and are not
+			-- counted as evidence.
+			SELECT c.player_id, COUNT(*) AS adjacent_count
+			FROM target_transitions tt
+			JOIN session_transitions c
+			  ON c.world = tt.world
+			 AND c.login <> tt.login
+			 AND c.bucket BETWEEN tt.bucket - @bucket_span AND tt.bucket + @bucket_span
+			 AND ABS(EXTRACT(EPOCH FROM (c.at - tt.at))) < @window
+			CROSS JOIN target_player tp
+			WHERE c.player_id <> tp.id
+			  AND NOT c.uncertain
 			  AND NOT EXISTS (
-				SELECT 1 FROM online_sessions s2
-				WHERE s2.player_id = p2.id
-				  AND EXISTS (
-					SELECT 1 FROM target_sessions ts
-					WHERE s2.login_at < COALESCE(ts.logout_at, NOW())
-					  AND COALESCE(s2.logout_at, NOW()) > ts.login_at
-				  )
+				SELECT 1 FROM polling_gaps g
+				WHERE g.world = tt.world
+				  AND (tt.at BETWEEN g.started_at AND g.ended_at
+				    OR c.at BETWEEN g.started_at AND g.ended_at)
 			  )
+			GROUP BY c.player_id
 		)
 		SELECT
-			c.name as character_name,
-			COUNT(*) as adjacent_count
-		FROM candidate_characters c
-		JOIN online_sessions s2 ON s2.player_id = c.id
-		CROSS JOIN target_sessions ts
-		WHERE
-			-- Adjacent transitions (within configured seconds)
-			(ABS(EXTRACT(EPOCH FROM (COALESCE(s2.logout_at, NOW()) - ts.login_at))) < ?
-			 OR ABS(EXTRACT(EPOCH FROM (COALESCE(ts.logout_at, NOW()) - s2.login_at))) < ?)
-		GROUP BY c.id, c.name
-		HAVING COUNT(*) >= 1
-		ORDER BY adjacent_count DESC
-		LIMIT ?
+			p2.id AS player_id,
+			p2.name AS character_name,
+			a.adjacent_count,
+			COALESCE(l.label, '') AS label
+		FROM adjacent a
+		JOIN players p2 ON p2.id = a.player_id
+		CROSS JOIN target_player tp
+		LEFT JOIN scan_labels l
+		  ON l.guild_id = @guild_id
+		 AND l.player_a = LEAST(tp.id, p2.id)
+		 AND l.player_b = GREATEST(tp.id, p2.id)
+		WHERE p2.level >= @min_level
+		  AND (@vocation = '' OR p2.vocation ILIKE '%' || @vocation || '%')
+		  -- Skip pairs a moderator marked as false positives
+		  AND (l.label IS NULL OR l.label <> @false_positive)
+		  -- Never online at the same time as the target. The target's
+		  -- sessions never overlap each other, so a candidate session
+		  -- overlaps one of them only if it overlaps the last target session
+		  -- starting before it ends: one index lookup per session.
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM player_sessions s2
+			CROSS JOIN LATERAL (
+				SELECT ts.logout_at
+				FROM player_sessions ts
+				WHERE ts.player_id = tp.id
+				  AND ts.login_at < COALESCE(s2.logout_at, NOW())
+				  AND (CAST(@from AS timestamptz) IS NULL OR ts.login_at >= @from)
+				  AND (CAST(@to AS timestamptz) IS NULL OR ts.login_at < @to)
+				ORDER BY ts.login_at DESC
+				LIMIT 1
+			) previous
+			WHERE s2.player_id = p2.id
+			  AND COALESCE(previous.logout_at, NOW()) > s2.login_at
+		  )
+		ORDER BY a.adjacent_count DESC
+		LIMIT @limit
 	`
 
-	var results []struct {
-		CharacterName string
-		AdjacentCount int
+	// Raw counts favor characters that relog a lot, so a wider pool is
+	// fetched and re-ranked by score.
+	var scanResults []ScanResult
+	err := database.DB.Raw(query, map[string]interface{}{
+		"target":         target.ID,
+		"from":           opts.From,
+		"to":             opts.To,
+		"min_level":      opts.MinLevel,
+		"vocation":       opts.Vocation,
+		"window":         opts.AdjacentWindowSeconds,
+		"bucket_span":    (opts.AdjacentWindowSeconds + database.TransitionBucketSeconds - 1) / database.TransitionBucketSeconds,
+		"limit":          opts.MaxResults * scanCandidatePoolFactor,
+		"guild_id":       opts.GuildID,
+		"false_positive": ScanLabelFalsePositive,
+	}).Scan(&scanResults).Error
+	if err != nil {
+		return nil, err
 	}
 
-	err := database.DB.Raw(query, characterName, characterName, adjacentWindowSeconds, adjacentWindowSeconds, maxResults).Scan(&results).Error
-	if err != nil {
+	if err := r.scoreResults(target, scanResults, opts); err != nil {
 		return nil, err
 	}
 
-	scanResults := make([]ScanResult, len(results))
-	for i, result := range results {
-		scanResults[i] = ScanResult{
-			CharacterName:   result.CharacterName,
-			AdjacentCount:   result.AdjacentCount,
-			ConfidenceLevel: "",
+	sort.SliceStable(scanResults, func(i, j int) bool {
+		if scanResults[i].Score != scanResults[j].Score {
+			return scanResults[i].Score > scanResults[j].Score
 		}
+		return scanResults[i].AdjacentCount > scanResults[j].AdjacentCount
+	})
+
+	if len(scanResults) > opts.MaxResults {
+		scanResults = scanResults[:opts.MaxResults]
 	}
 
 	return scanResults, nil
 }
+
+// ExplainScan returns the transitions and overlap check that ScanCharacter
+// would use to score candidate against target with the same options, so
+// CountedTransitions matches the candidate's AdjacentCount.
+func (r *OnlineSessionRepository) ExplainScan(target, candidate *database.Player, opts ScanOptions) (*ScanExplanation, error) {
+	explanation := &ScanExplanation{
+		Target:    *target,
+		Candidate: *candidate,
+		SameWorld: target.World == "" || target.World == candidate.World,
+	}
+
+	transitionsQuery := `
+		WITH target_transitions AS (
+			SELECT t.world, t.login, t.bucket, t.at, t.uncertain
+			FROM session_transitions t
+			WHERE t.player_id = @target
+			  AND (CAST(@from AS timestamptz) IS NULL OR t.at >= @from)
+			  AND (CAST(@to AS timestamptz) IS NULL OR t.at < @to)
+		),
+		pairs AS (
+			SELECT
+				NOT tt.login AS target_first,
+				CASE WHEN tt.login THEN c.at ELSE tt.at END AS logout_at,
+				CASE WHEN tt.login THEN tt.at ELSE c.at END AS login_at,
+				(tt.uncertain OR c.uncertain OR EXISTS (
+					SELECT 1 FROM polling_gaps g
+					WHERE g.world = tt.world
+					  AND (tt.at BETWEEN g.started_at AND g.ended_at
+					    OR c.at BETWEEN g.started_at AND g.ended_at)
+				)) AS uncertain
+			FROM target_transitions tt
+			JOIN session_transitions c
+			  ON c.player_id = @candidate
+			 AND c.world = tt.world
+			 AND c.login <> tt.login
+			 AND c.bucket BETWEEN tt.bucket - @bucket_span AND tt.bucket + @bucket_span
+			 AND ABS(EXTRACT(EPOCH FROM (c.at - tt.at))) < @window
+		)
+		SELECT
+			p.target_first,
+			p.logout_at,
+			p.login_at,
+			EXTRACT(EPOCH FROM (p.login_at - p.logout_at)) AS gap_seconds,
+			p.uncertain
+		FROM pairs p
+		ORDER BY p.logout_at DESC
+	`
+
+	err := database.DB.Raw(transitionsQuery, map[string]interface{}{
+		"target":      target.ID,
+		"candidate":   candidate.ID,
+		"from":        opts.From,
+		"to":          opts.To,
+		"window":      opts.AdjacentWindowSeconds,
+		"bucket_span": (opts.AdjacentWindowSeconds + database.TransitionBucketSeconds - 1) / database.TransitionBucketSeconds,
+	}).Scan(&explanation.Transitions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transition := range explanation.Transitions {
+		if !transition.Uncertain {
+			explanation.CountedTransitions++
+		}
+	}
+
+	// Candidate sessions overlapping any of the target's sessions starting
+	// in [From, To), with open sessions running until now as in
+	// ScanCharacter.
+	overlapQuery := `
+		SELECT
+			COUNT(*) AS overlapping_sessions,
+			COALESCE(SUM(o.seconds), 0) AS overlap_seconds
+		FROM player_sessions s2
+		CROSS JOIN LATERAL (
+			SELECT SUM(EXTRACT(EPOCH FROM (
+				LEAST(COALESCE(ts.logout_at, NOW()), COALESCE(s2.logout_at, NOW())) - GREATEST(ts.login_at, s2.login_at)
+			))) AS seconds
+			FROM player_sessions ts
+			WHERE ts.player_id = @target
+			  AND ts.login_at < COALESCE(s2.logout_at, NOW())
+			  AND COALESCE(ts.logout_at, NOW()) > s2.login_at
+			  AND (CAST(@from AS timestamptz) IS NULL OR ts.login_at >= @from)
+			  AND (CAST(@to AS timestamptz) IS NULL OR ts.login_at < @to)
+		) o
+		WHERE s2.player_id = @candidate
+		  AND o.seconds IS NOT NULL
+	`
+
+	var overlap struct {
+		OverlappingSessions int
+		OverlapSeconds      float64
+	}
+	err = database.DB.Raw(overlapQuery, map[string]interface{}{
+		"target":    target.ID,
+		"candidate": candidate.ID,
+		"from":      opts.From,
+		"to":        opts.To,
+	}).Scan(&overlap).Error
+	if err != nil {
+		return nil, err
+	}
+
+	explanation.OverlappingSessions = overlap.OverlappingSessions
+	explanation.OverlapSeconds = overlap.OverlapSeconds
+
+	return explanation, nil
+}
@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+)
+
+// scanBenchWorld names the synthetic world. The benchmark refuses to run
+// when the database already has players on it.
+const scanBenchWorld = "Scanbench"
+
+// scanBenchWindowSeconds matches the default /scan window.
+const scanBenchWindowSeconds = 60
+
+var (
+	scanBenchPlayers  = flag.Int("scanbench.players", 20000, "number of synthetic players")
+	scanBenchSessions = flag.Int("scanbench.sessions", 2000000, "total number of synthetic sessions")
+	scanBenchDays     = flag.Int("scanbench.days", 90, "days of history to spread the sessions over")
+	scanBenchTargets  = flag.Int("scanbench.targets", 5, "number of targets with a planted alt")
+	scanBenchBudget   = flag.Duration("scanbench.budget", time.Second, "slowest acceptable average scan")
+)
+
+type plantedAlt struct {
+	target database.Player
+	alt    database.Player
+}
+
+// BenchmarkScanCharacter times ScanCharacter against a synthetic world of
+// random players and sessions, with one alt planted next to each of a few
+// targets. It needs a throwaway database in SCANBENCH_DATABASE_URL:
+//
+//	SCANBENCH_DATABASE_URL=postgres://localhost/scanbench \
+//		go test ./pkg/repositories -run '^$' -bench ScanCharacter -benchtime 20x
+func BenchmarkScanCharacter(b *testing.B) {
+	dsn := os.Getenv("SCANBENCH_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("SCANBENCH_DATABASE_URL is not set")
+	}
+	if dsn == os.Getenv("DATABASE_URL") {
+		b.Fatal("SCANBENCH_DATABASE_URL must not be the bot's DATABASE_URL")
+	}
+	if *scanBenchPlayers < 2**scanBenchTargets || *scanBenchSessions < *scanBenchPlayers {
+		b.Fatal("need at least two players per target and one session per player")
+	}
+
+	if err := database.Connect(dsn); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { database.Close() })
+
+	if err := database.AutoMigrate(); err != nil {
+		b.Fatal(err)
+	}
+
+	var existing int64
+	if err := database.DB.Model(&database.Player{}).Where("world = ?", scanBenchWorld).Count(&existing).Error; err != nil {
+		b.Fatal(err)
+	}
+	if existing > 0 {
+		b.Fatalf("world %q already has %d players; use an empty database", scanBenchWorld, existing)
+	}
+	b.Cleanup(func() {
+		if err := removeSyntheticWorld(); err != nil {
+			b.Errorf("removing synthetic world: %v", err)
+		}
+	})
+
+	planted, err := buildSyntheticWorld()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	repo := NewOnlineSessionRepository()
+	opts := ScanOptions{
+		AdjacentWindowSeconds: scanBenchWindowSeconds,
+		MaxResults:            10,
+	}
+
+	for _, pair := range planted {
+		results, err := repo.ScanCharacter(&pair.target, opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if !containsCandidate(results, pair.alt.ID) {
+			b.Errorf("planted alt %s not found for %s", pair.alt.Name, pair.target.Name)
+		}
+	}
+
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.ScanCharacter(&planted[i%len(planted)].target, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StopTimer()
+
+		if average := b.Elapsed() / time.Duration(b.N); average > *scanBenchBudget {
+			b.Errorf("scans took %s on average, over the %s budget", average, *scanBenchBudget)
+		}
+	})
+}
+
+func containsCandidate(results []ScanResult, playerID uint) bool {
+	for _, result := range results {
+		if result.PlayerID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSyntheticWorld creates the synthetic players, gives each the same
+// number of sessions spread over the history with random start times and
+// lengths that never overlap each other, plants the alts and indexes the
+// transitions.
+func buildSyntheticWorld() ([]plantedAlt, error) {
+	err := database.DB.Exec(`
+		INSERT INTO players (name, world, level, vocation, country, created_at, updated_at)
+		SELECT
+			@world || ' ' || LPAD(g::text, 7, '0'),
+			@world,
+			8 + (random() * 500)::int,
+			(ARRAY['Elite Knight', 'Royal Paladin', 'Master Sorcerer', 'Elder Druid'])[1 + (random() * 3)::int],
+			'',
+			NOW(),
+			NOW()
+		FROM generate_series(1, @players) g
+	`, map[string]interface{}{
+		"world":   scanBenchWorld,
+		"players": *scanBenchPlayers,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	perPlayer := *scanBenchSessions / *scanBenchPlayers
+	history := time.Duration(*scanBenchDays) * 24 * time.Hour
+	slot := history.Seconds() / float64(perPlayer)
+
+	err = database.DB.Exec(`
+		INSERT INTO online_sessions (player_id, world, login_at, logout_at, created_at)
+		SELECT p.id, p.world, l.at, l.at + (60 + random() * @slot * 0.4) * INTERVAL '1 second', NOW()
+		FROM players p
+		CROSS JOIN generate_series(0, @per_player - 1) k
+		CROSS JOIN LATERAL (
+			SELECT CAST(@start AS timestamptz) + (k * @slot + random() * @slot * 0.5) * INTERVAL '1 second' AS at
+		) l
+		WHERE p.world = @world
+	`, map[string]interface{}{
+		"world":      scanBenchWorld,
+		"per_player": perPlayer,
+		"slot":       slot,
+		"start":      time.Now().Add(-history),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	planted, err := plantAlts()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := NewSessionTransitionRepository().IndexWorld(scanBenchWorld); err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Exec("ANALYZE online_sessions, session_transitions").Error; err != nil {
+		return nil, err
+	}
+
+	return planted, nil
+}
+
+// plantAlts replaces the sessions of the player after each target with ones
+// that start just after one of the target's sessions ends and end just
+// before its next one starts, like a second character on the same account.
+func plantAlts() ([]plantedAlt, error) {
+	var players []database.Player
+	err := database.DB.Where("world = ?", scanBenchWorld).
+		Order("id").
+		Limit(2 * *scanBenchTargets).
+		Find(&players).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var planted []plantedAlt
+	for i := 0; i+1 < len(players); i += 2 {
+		target, alt := players[i], players[i+1]
+
+		if err := database.DB.Where("player_id = ?", alt.ID).Delete(&database.OnlineSession{}).Error; err != nil {
+			return nil, err
+		}
+
+		// Every other gap between the target's sessions is used, so the alt
+		// plays about half as often as the target.
+		err := database.DB.Exec(`
+			INSERT INTO online_sessions (player_id, world, login_at, logout_at, created_at)
+			SELECT @alt, world,
+				logout_at + (2 + random() * 20) * INTERVAL '1 second',
+				next_login - (2 + random() * 20) * INTERVAL '1 second',
+				NOW()
+			FROM (
+				SELECT
+					world,
+					logout_at,
+					LEAD(login_at) OVER (ORDER BY login_at) AS next_login,
+					ROW_NUMBER() OVER (ORDER BY login_at) AS n
+				FROM online_sessions
+				WHERE player_id = @target
+			) gaps
+			WHERE next_login IS NOT NULL
+			  AND next_login - logout_at > INTERVAL '2 minutes'
+			  AND n % 2 = 0
+		`, map[string]interface{}{
+			"alt":    alt.ID,
+			"target": target.ID,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+
+		planted = append(planted, plantedAlt{target: target, alt: alt})
+	}
+
+	return planted, nil
+}
+
+// removeSyntheticWorld deletes everything the benchmark created. The world
+// was empty before it ran, so this only touches synthetic rows.
+func removeSyntheticWorld() error {
+	if err := database.DB.Where("world = ?", scanBenchWorld).Delete(&database.SessionTransition{}).Error; err != nil {
+		return err
+	}
+	if err := database.DB.Where("world = ?", scanBenchWorld).Delete(&database.OnlineSession{}).Error; err != nil {
+		return err
+	}
+	return database.DB.Where("world = ?", scanBenchWorld).Delete(&database.Player{}).Error
+}
@@ -1,25 +1,41 @@
 package repositories
 
 import (
+	"strings"
+	"time"
+
 	"github.com/ethaan/discord-api/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// upsertBatchSize keeps each statement well below Postgres' parameter limit.
+const upsertBatchSize = 500
+
 type PlayerRepository struct{}
 
 func NewPlayerRepository() *PlayerRepository {
 	return &PlayerRepository{}
 }
 
-func (r *PlayerRepository) FindOrCreate(name string, level int, vocation, country string) (*database.Player, error) {
+func (r *PlayerRepository) FindOrCreate(name, world string, level int, vocation, country string) (*database.Player, error) {
 	var player database.Player
 
 	result := database.DB.Where("name = ?", name).First(&player)
 	if result.Error == nil {
-		if player.Level != level || player.Vocation != vocation || player.Country != country {
+		if player.World != world || player.Level != level || player.Vocation != vocation || player.Country != country {
+			player.World = world
 			player.Level = level
 			player.Vocation = vocation
 			player.Country = country
-			if err := database.DB.Save(&player).Error; err != nil {
+			err := database.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Save(&player).Error; err != nil {
+					return err
+				}
+				snapshot := snapshotOf(player, time.Now())
+				return tx.Create(&snapshot).Error
+			})
+			if err != nil {
 				return nil, err
 			}
 		}
@@ -28,18 +44,100 @@ func (r *PlayerRepository) FindOrCreate(name string, level int, vocation, countr
 
 	player = database.Player{
 		Name:     name,
+		World:    world,
 		Level:    level,
 		Vocation: vocation,
 		Country:  country,
 	}
 
-	if err := database.DB.Create(&player).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&player).Error; err != nil {
+			return err
+		}
+		snapshot := snapshotOf(player, time.Now())
+		return tx.Create(&snapshot).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &player, nil
 }
 
+// UpsertBatch inserts or updates players by name and fills in their IDs,
+// recording a snapshot for every player that is new or has changed. Names
+// must be unique within the slice.
+func (r *PlayerRepository) UpsertBatch(players []database.Player) error {
+	if len(players) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(players))
+	for i, player := range players {
+		names[i] = player.Name
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []database.Player
+		if err := tx.Where("name IN ?", names).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		previous := make(map[string]database.Player, len(existing))
+		for _, player := range existing {
+			previous[player.Name] = player
+		}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"world", "level", "vocation", "country", "updated_at"}),
+		}).CreateInBatches(&players, upsertBatchSize).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var snapshots []database.PlayerSnapshot
+		for _, player := range players {
+			if old, ok := previous[player.Name]; ok && !trackedValuesChanged(old, player) {
+				continue
+			}
+			snapshots = append(snapshots, snapshotOf(player, now))
+		}
+
+		if len(snapshots) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&snapshots, upsertBatchSize).Error
+	})
+}
+
+func trackedValuesChanged(old, current database.Player) bool {
+	return old.World != current.World ||
+		old.Level != current.Level ||
+		old.Vocation != current.Vocation ||
+		old.Country != current.Country
+}
+
+func snapshotOf(player database.Player, at time.Time) database.PlayerSnapshot {
+	return database.PlayerSnapshot{
+		PlayerID:   player.ID,
+		World:      player.World,
+		Level:      player.Level,
+		Vocation:   player.Vocation,
+		Country:    player.Country,
+		RecordedAt: at,
+	}
+}
+
+func (r *PlayerRepository) FindByID(id uint) (*database.Player, error) {
+	var player database.Player
+	if err := database.DB.First(&player, id).Error; err != nil {
+		return nil, err
+	}
+	return &player, nil
+}
+
 func (r *PlayerRepository) FindByName(name string) (*database.Player, error) {
 	var player database.Player
 	if err := database.DB.Where("name = ?", name).First(&player).Error; err != nil {
@@ -47,3 +145,143 @@ func (r *PlayerRepository) FindByName(name string) (*database.Player, error) {
 	}
 	return &player, nil
 }
+
+// FindByNames returns the tracked players matching any of the given names,
+// compared case-insensitively.
+func (r *PlayerRepository) FindByNames(names []string) ([]database.Player, error) {
+	var players []database.Player
+	if len(names) == 0 {
+		return players, nil
+	}
+
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+
+	err := database.DB.Where("LOWER(name) IN ?", lowered).Find(&players).Error
+	return players, err
+}
+
+// MergeRename moves a renamed character's history onto a single player
+// record. If the tracker has already created a player under newName, every
+// row referring to it is moved to the old record and it is deleted; the old
+// record then takes the new name.
+func (r *PlayerRepository) MergeRename(oldName, newName string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var oldPlayers []database.Player
+		if err := tx.Where("LOWER(name) = LOWER(?)", oldName).Limit(1).Find(&oldPlayers).Error; err != nil {
+			return err
+		}
+		if len(oldPlayers) == 0 {
+			// Nothing tracked under the old name, so there is no history to keep.
+			return nil
+		}
+		oldPlayer := oldPlayers[0]
+
+		var newPlayers []database.Player
+		if err := tx.Where("LOWER(name) = LOWER(?)", newName).Limit(1).Find(&newPlayers).Error; err != nil {
+			return err
+		}
+
+		if len(newPlayers) > 0 && newPlayers[0].ID != oldPlayer.ID {
+			newPlayer := newPlayers[0]
+
+			if err := mergePlayerRows(tx, newPlayer.ID, oldPlayer.ID); err != nil {
+				return err
+			}
+			if err := tx.Delete(&newPlayer).Error; err != nil {
+				return err
+			}
+
+			oldPlayer.World = newPlayer.World
+			oldPlayer.Level = newPlayer.Level
+			oldPlayer.Vocation = newPlayer.Vocation
+			oldPlayer.Country = newPlayer.Country
+		}
+
+		oldPlayer.Name = newName
+		return tx.Save(&oldPlayer).Error
+	})
+}
+
+// mergePlayerRows re-points every row referring to player from at player to.
+// Tables keyed by player are merged: daily activity and edge counts are
+// added up, and edges or labels between the two players themselves are
+// dropped, since a character cannot be its own alt.
+func mergePlayerRows(tx *gorm.DB, from, to uint) error {
+	params := map[string]interface{}{
+		"from": from,
+		"to":   to,
+	}
+
+	statements := []string{
+		"UPDATE online_sessions SET player_id = @to WHERE player_id = @from",
+		"UPDATE session_transitions SET player_id = @to WHERE player_id = @from",
+		"UPDATE player_snapshots SET player_id = @to WHERE player_id = @from",
+		"UPDATE online_sessions_archive SET player_id = @to WHERE player_id = @from",
+
+		`INSERT INTO player_daily_activities
+			(player_id, day, world, online_minutes, longest_minutes, sessions, first_seen, last_seen)
+		SELECT @to, day, world, online_minutes, longest_minutes, sessions, first_seen, last_seen
+		FROM player_daily_activities
+		WHERE player_id = @from
+		ON CONFLICT (player_id, day) DO UPDATE
+		SET online_minutes = player_daily_activities.online_minutes + EXCLUDED.online_minutes,
+		    longest_minutes = GREATEST(player_daily_activities.longest_minutes, EXCLUDED.longest_minutes),
+		    sessions = player_daily_activities.sessions + EXCLUDED.sessions,
+		    first_seen = LEAST(player_daily_activities.first_seen, EXCLUDED.first_seen),
+		    last_seen = GREATEST(player_daily_activities.last_seen, EXCLUDED.last_seen)`,
+		"DELETE FROM player_daily_activities WHERE player_id = @from",
+
+		// The score of a merged edge is only recomputed the next time the
+		// alt graph touches it, so the stronger of the two is kept until then.
+		`INSERT INTO alt_edges (player_a, player_b, transitions, overlaps, score, created_at, updated_at)
+		SELECT LEAST(@to, other), GREATEST(@to, other), transitions, overlaps, score, created_at, NOW()
+		FROM (
+			SELECT CASE WHEN player_a = @from THEN player_b ELSE player_a END AS other, transitions, overlaps, score, created_at
+			FROM alt_edges
+			WHERE player_a = @from OR player_b = @from
+		) moved
+		WHERE other <> @to
+		ON CONFLICT (player_a, player_b) DO UPDATE
+		SET transitions = alt_edges.transitions + EXCLUDED.transitions,
+		    overlaps = alt_edges.overlaps + EXCLUDED.overlaps,
+		    score = GREATEST(alt_edges.score, EXCLUDED.score),
+		    updated_at = EXCLUDED.updated_at`,
+		"DELETE FROM alt_edges WHERE player_a = @from OR player_b = @from",
+
+		// Clusters are rebuilt from the edges on the next alt graph run; until
+		// then the merged player keeps whichever membership it had.
+		`UPDATE player_clusters SET player_id = @to
+		WHERE player_id = @from
+		  AND NOT EXISTS (SELECT 1 FROM player_clusters WHERE player_id = @to)`,
+		"DELETE FROM player_clusters WHERE player_id = @from",
+
+		// When both players were labeled against the same character, the
+		// most recent label wins.
+		`INSERT INTO scan_labels (guild_id, player_a, player_b, label, score, labeled_by, created_at, updated_at)
+		SELECT guild_id, LEAST(@to, other), GREATEST(@to, other), label, score, labeled_by, created_at, updated_at
+		FROM (
+			SELECT guild_id, CASE WHEN player_a = @from THEN player_b ELSE player_a END AS other,
+				label, score, labeled_by, created_at, updated_at
+			FROM scan_labels
+			WHERE player_a = @from OR player_b = @from
+		) moved
+		WHERE other <> @to
+		ON CONFLICT (guild_id, player_a, player_b) DO UPDATE
+		SET label = EXCLUDED.label,
+		    score = EXCLUDED.score,
+		    labeled_by = EXCLUDED.labeled_by,
+		    updated_at = EXCLUDED.updated_at
+		WHERE EXCLUDED.updated_at > scan_labels.updated_at`,
+		"DELETE FROM scan_labels WHERE player_a = @from OR player_b = @from",
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement, params).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
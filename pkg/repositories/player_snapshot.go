@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+)
+
+// LevelPoint is a player's level from RecordedAt until the next point.
+type LevelPoint struct {
+	Level      int
+	Vocation   string
+	RecordedAt time.Time
+}
+
+type PlayerSnapshotRepository struct{}
+
+func NewPlayerSnapshotRepository() *PlayerSnapshotRepository {
+	return &PlayerSnapshotRepository{}
+}
+
+// FindByPlayerID returns a player's snapshots recorded in [from, to], oldest
+// first.
+func (r *PlayerSnapshotRepository) FindByPlayerID(playerID uint, from, to time.Time) ([]database.PlayerSnapshot, error) {
+	var snapshots []database.PlayerSnapshot
+	err := database.DB.Where("player_id = ? AND recorded_at BETWEEN ? AND ?", playerID, from, to).
+		Order("recorded_at ASC").
+		Find(&snapshots).Error
+	return snapshots, err
+}
+
+// LevelProgression returns the level changes of a player over [from, to],
+// oldest first. The first point is the level the player already had at
+// from, when known, so the series covers the whole range.
+func (r *PlayerSnapshotRepository) LevelProgression(playerID uint, from, to time.Time) ([]LevelPoint, error) {
+	var before []database.PlayerSnapshot
+	err := database.DB.Where("player_id = ? AND recorded_at < ?", playerID, from).
+		Order("recorded_at DESC").
+		Limit(1).
+		Find(&before).Error
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := r.FindByPlayerID(playerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]LevelPoint, 0, len(snapshots)+1)
+	if len(before) > 0 {
+		points = append(points, LevelPoint{
+			Level:      before[0].Level,
+			Vocation:   before[0].Vocation,
+			RecordedAt: from,
+		})
+	}
+
+	for _, snapshot := range snapshots {
+		// Snapshots also cover world and country changes; only keep the
+		// ones that moved the level or vocation.
+		if n := len(points); n > 0 && points[n-1].Level == snapshot.Level && points[n-1].Vocation == snapshot.Vocation {
+			continue
+		}
+		points = append(points, LevelPoint{
+			Level:      snapshot.Level,
+			Vocation:   snapshot.Vocation,
+			RecordedAt: snapshot.RecordedAt,
+		})
+	}
+
+	return points, nil
+}
+
+// LevelsGained returns how many levels a player gained (negative if lost)
+// over [from, to].
+func (r *PlayerSnapshotRepository) LevelsGained(playerID uint, from, to time.Time) (int, error) {
+	points, err := r.LevelProgression(playerID, from, to)
+	if err != nil || len(points) == 0 {
+		return 0, err
+	}
+	return points[len(points)-1].Level - points[0].Level, nil
+}
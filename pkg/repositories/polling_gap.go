@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+)
+
+type PollingGapRepository struct{}
+
+func NewPollingGapRepository() *PollingGapRepository {
+	return &PollingGapRepository{}
+}
+
+func (r *PollingGapRepository) Create(world string, startedAt, endedAt time.Time) error {
+	gap := database.PollingGap{
+		World:     world,
+		StartedAt: startedAt,
+		EndedAt:   endedAt,
+	}
+	return database.DB.Create(&gap).Error
+}
+
+// FindOverlapping returns the gaps in world that overlap [from, to].
+func (r *PollingGapRepository) FindOverlapping(world string, from, to time.Time) ([]database.PollingGap, error) {
+	var gaps []database.PollingGap
+	err := database.DB.Where("world = ? AND started_at <= ? AND ended_at >= ?", world, to, from).
+		Order("started_at ASC").
+		Find(&gaps).Error
+	return gaps, err
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"github.com/ethaan/discord-api/pkg/database"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ScanLabelConfirmed     = "confirmed"
+	ScanLabelFalsePositive = "false_positive"
+)
+
+type ScanLabelRepository struct{}
+
+func NewScanLabelRepository() *ScanLabelRepository {
+	return &ScanLabelRepository{}
+}
+
+// Save labels the pair of players for a guild, replacing any earlier label.
+func (r *ScanLabelRepository) Save(guildID string, playerID, otherID uint, label string, score float64, labeledBy string) error {
+	scanLabel := database.ScanLabel{
+		GuildID:   guildID,
+		PlayerA:   min(playerID, otherID),
+		PlayerB:   max(playerID, otherID),
+		Label:     label,
+		Score:     score,
+		LabeledBy: labeledBy,
+	}
+
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "guild_id"}, {Name: "player_a"}, {Name: "player_b"}},
+		DoUpdates: clause.AssignmentColumns([]string{"label", "score", "labeled_by", "updated_at"}),
+	}).Create(&scanLabel).Error
+}
+
+// FindConfirmed returns the players confirmed as alts of playerID in a guild.
+func (r *ScanLabelRepository) FindConfirmed(guildID string, playerID uint) ([]database.Player, error) {
+	var players []database.Player
+	err := database.DB.
+		Joins("JOIN scan_labels l ON (l.player_a = players.id AND l.player_b = ?) OR (l.player_b = players.id AND l.player_a = ?)",
+			playerID, playerID).
+		Where("l.guild_id = ? AND l.label = ?", guildID, ScanLabelConfirmed).
+		Order("players.name").
+		Find(&players).Error
+	return players, err
+}
+
+func (r *ScanLabelRepository) FindByGuild(guildID string) ([]database.ScanLabel, error) {
+	var labels []database.ScanLabel
+	err := database.DB.Where("guild_id = ?", guildID).Find(&labels).Error
+	return labels, err
+}
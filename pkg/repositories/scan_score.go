@@ -0,0 +1,285 @@
+package repositories
+
+import (
+	"math"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"gorm.io/gorm"
+)
+
+const (
+	// scanCandidatePoolFactor is how many raw-count candidates per requested
+	// result are scored before trimming to the best ones.
+	scanCandidatePoolFactor = 5
+
+	// scanBaseRateDays is how much world history is used to estimate how busy
+	// each hour of the day is.
+	scanBaseRateDays = 30
+
+	// minObservedSpan keeps candidates seen only briefly from getting an
+	// absurdly high transition rate.
+	minObservedSpan = time.Hour
+
+	maxScanScore = 99
+
+	// rateBatchSize bounds the players looked up per sessionRates query.
+	rateBatchSize = 1000
+)
+
+// scoreResults fills ExpectedCount and Score for each result.
+//
+// Under the null hypothesis the candidate's logins and logouts are
+// independent of the target's. A target transition at hour h then has a
+// candidate transition of the opposite kind within the window with
+// probability rate * 2 * window * weight(h), where rate is the candidate's
+// sessions per second over the time it has been observed and weight(h) is
+// how much busier the world is at h than on average. Summing over the
+// target's transitions gives the expected count, and the score is the
+// Poisson tail probability of the observed count, as -log10. Both the
+// target's transitions and the candidates' rates are taken from the same
+// [From, To) range as the observed counts.
+func (r *OnlineSessionRepository) scoreResults(target *database.Player, results []ScanResult, opts ScanOptions) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	targetHours, err := transitionHours(target.ID, opts.From, opts.To)
+	if err != nil {
+		return err
+	}
+
+	weights, err := hourWeights(target.World)
+	if err != nil {
+		return err
+	}
+
+	var weightedTransitions float64
+	for hour, count := range targetHours {
+		weightedTransitions += count * weights[hour]
+	}
+
+	playerIDs := make([]uint, len(results))
+	for i, result := range results {
+		playerIDs[i] = result.PlayerID
+	}
+
+	rates, err := sessionRates(database.DB, playerIDs, opts.From, opts.To)
+	if err != nil {
+		return err
+	}
+
+	window := 2 * float64(opts.AdjacentWindowSeconds)
+	for i := range results {
+		expected := rates[results[i].PlayerID].Rate * window * weightedTransitions
+		results[i].ExpectedCount = expected
+		results[i].Score = poissonTailScore(results[i].AdjacentCount, expected)
+	}
+
+	return nil
+}
+
+// sessionRate is how often a player starts a session.
+type sessionRate struct {
+	Sessions float64
+	// Rate is sessions per second over the time the player has been
+	// observed.
+	Rate float64
+}
+
+// sessionRates returns the session counts and rates of the given players
+// over [from, to); nil bounds leave that side open, and to never goes past
+// now. Sessions already rolled up into daily activity are included, so
+// rates keep reflecting the whole range after raw sessions expire.
+func sessionRates(tx *gorm.DB, playerIDs []uint, from, to *time.Time) (map[uint]sessionRate, error) {
+	rates := make(map[uint]sessionRate, len(playerIDs))
+
+	until := time.Now()
+	if to != nil && to.Before(until) {
+		until = *to
+	}
+
+	for start := 0; start < len(playerIDs); start += rateBatchSize {
+		end := min(start+rateBatchSize, len(playerIDs))
+
+		var rows []struct {
+			PlayerID  uint
+			Sessions  int
+			FirstSeen time.Time
+			LastSeen  time.Time
+		}
+		err := tx.Raw(`
+			SELECT player_id, SUM(sessions) AS sessions, MIN(first_seen) AS first_seen, MAX(last_seen) AS last_seen
+			FROM (
+				SELECT
+					player_id,
+					COUNT(*) AS sessions,
+					MIN(login_at) AS first_seen,
+					MAX(LEAST(COALESCE(logout_at, @until), @until)) AS last_seen
+				FROM online_sessions
+				WHERE player_id IN @ids
+				  AND login_at < @until
+				  AND (CAST(@from AS timestamptz) IS NULL OR login_at >= @from)
+				GROUP BY player_id
+				UNION ALL
+				SELECT player_id, SUM(sessions), MIN(first_seen), MAX(last_seen)
+				FROM player_daily_activities
+				WHERE player_id IN @ids
+				  AND first_seen < @until
+				  AND (CAST(@from AS timestamptz) IS NULL OR first_seen >= @from)
+				GROUP BY player_id
+			) activity
+			GROUP BY player_id
+		`, map[string]interface{}{
+			"ids":   playerIDs[start:end],
+			"from":  from,
+			"until": until,
+		}).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			span := row.LastSeen.Sub(row.FirstSeen)
+			if span < minObservedSpan {
+				span = minObservedSpan
+			}
+			rates[row.PlayerID] = sessionRate{
+				Sessions: float64(row.Sessions),
+				Rate:     float64(row.Sessions) / span.Seconds(),
+			}
+		}
+	}
+
+	return rates, nil
+}
+
+// transitionHours counts a player's logins and logouts in [from, to) per
+// UTC hour of day. Only the transitions ScanCharacter counts as evidence are
+// included: uncertain ones and those inside polling gaps are skipped.
+func transitionHours(playerID uint, from, to *time.Time) ([24]float64, error) {
+	var hours [24]float64
+
+	var rows []struct {
+		Hour  int
+		Count float64
+	}
+	err := database.DB.Raw(`
+		SELECT EXTRACT(HOUR FROM t.at AT TIME ZONE 'UTC')::int AS hour, COUNT(*) AS count
+		FROM session_transitions t
+		WHERE t.player_id = @player
+		  AND NOT t.uncertain
+		  AND (CAST(@from AS timestamptz) IS NULL OR t.at >= @from)
+		  AND (CAST(@to AS timestamptz) IS NULL OR t.at < @to)
+		  AND NOT EXISTS (
+			SELECT 1 FROM polling_gaps g
+			WHERE g.world = t.world
+			  AND t.at BETWEEN g.started_at AND g.ended_at
+		  )
+		GROUP BY hour
+	`, map[string]interface{}{
+		"player": playerID,
+		"from":   from,
+		"to":     to,
+	}).Scan(&rows).Error
+	if err != nil {
+		return hours, err
+	}
+
+	for _, row := range rows {
+		if row.Hour >= 0 && row.Hour < 24 {
+			hours[row.Hour] = row.Count
+		}
+	}
+	return hours, nil
+}
+
+// hourWeights returns, for each UTC hour, the world's login rate relative to
+// its daily average. Hours without data fall back to 1. Logins are read from
+// the transition index, which keeps them after their sessions are rolled up.
+func hourWeights(world string) ([24]float64, error) {
+	weights := [24]float64{}
+	for hour := range weights {
+		weights[hour] = 1
+	}
+
+	var rows []struct {
+		Hour  int
+		Count float64
+	}
+	since := time.Now().AddDate(0, 0, -scanBaseRateDays)
+	err := database.DB.Raw(`
+		SELECT EXTRACT(HOUR FROM at AT TIME ZONE 'UTC')::int AS hour, COUNT(*) AS count
+		FROM session_transitions
+		WHERE world = @world
+		  AND login
+		  AND bucket >= @bucket
+		  AND at > @since
+		GROUP BY hour
+	`, map[string]interface{}{
+		"world":  world,
+		"bucket": since.Unix() / database.TransitionBucketSeconds,
+		"since":  since,
+	}).Scan(&rows).Error
+	if err != nil {
+		return weights, err
+	}
+
+	var total float64
+	for _, row := range rows {
+		total += row.Count
+	}
+	if total == 0 {
+		return weights, nil
+	}
+
+	for _, row := range rows {
+		if row.Hour >= 0 && row.Hour < 24 {
+			weights[row.Hour] = row.Count / total * 24
+		}
+	}
+	return weights, nil
+}
+
+// poissonTailScore returns -log10 P(X >= k) for X ~ Poisson(lambda), capped
+// at maxScanScore.
+func poissonTailScore(k int, lambda float64) float64 {
+	if k <= 0 {
+		return 0
+	}
+	if lambda <= 0 {
+		return maxScanScore
+	}
+
+	// Sum the tail terms in log space; they shrink quickly once i > lambda.
+	logLambda := math.Log(lambda)
+	logTail := math.Inf(-1)
+	for i := k; i < k+1000; i++ {
+		lgamma, _ := math.Lgamma(float64(i + 1))
+		term := -lambda + float64(i)*logLambda - lgamma
+		logTail = logAdd(logTail, term)
+		if float64(i) > lambda && term < logTail-40 {
+			break
+		}
+	}
+
+	score := -logTail / math.Ln10
+	if score < 0 {
+		return 0
+	}
+	if score > maxScanScore {
+		return maxScanScore
+	}
+	return score
+}
+
+// logAdd returns log(exp(a) + exp(b)) without overflowing.
+func logAdd(a, b float64) float64 {
+	if math.IsInf(a, -1) {
+		return b
+	}
+	if b > a {
+		a, b = b, a
+	}
+	return a + math.Log1p(math.Exp(b-a))
+}
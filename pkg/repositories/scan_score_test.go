@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"math"
+	"testing"
+)
+
+// poissonTail computes P(X >= k) for X ~ Poisson(lambda) in plain floating
+// point, as a reference for moderate k and lambda. Far tails are summed
+// upwards, since 1 minus the lower terms rounds to zero there.
+func poissonTail(k int, lambda float64) float64 {
+	term := math.Exp(-lambda)
+	below := 0.0
+	for i := 0; i < k; i++ {
+		below += term
+		term *= lambda / float64(i+1)
+	}
+	if float64(k) <= lambda {
+		return 1 - below
+	}
+
+	tail := 0.0
+	for i := k; term > 0 && i < k+1000; i++ {
+		tail += term
+		term *= lambda / float64(i+1)
+	}
+	return tail
+}
+
+func TestPoissonTailScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		k      int
+		lambda float64
+		want   float64
+	}{
+		{name: "no transitions", k: 0, lambda: 3, want: 0},
+		{name: "negative count", k: -1, lambda: 3, want: 0},
+		{name: "nothing expected", k: 1, lambda: 0, want: maxScanScore},
+		{name: "one as expected", k: 1, lambda: 1, want: -math.Log10(poissonTail(1, 1))},
+		{name: "fewer than expected", k: 2, lambda: 10, want: -math.Log10(poissonTail(2, 10))},
+		{name: "more than expected", k: 10, lambda: 1, want: -math.Log10(poissonTail(10, 1))},
+		{name: "far more than expected", k: 40, lambda: 2, want: -math.Log10(poissonTail(40, 2))},
+		{name: "capped", k: 500, lambda: 0.01, want: maxScanScore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := poissonTailScore(tt.k, tt.lambda)
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("poissonTailScore(%d, %g) = %g, want %g", tt.k, tt.lambda, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoissonTailScoreGrowsWithCount(t *testing.T) {
+	for _, lambda := range []float64{0.5, 5, 50} {
+		previous := 0.0
+		for k := 1; k <= 200; k++ {
+			score := poissonTailScore(k, lambda)
+			if score < previous {
+				t.Fatalf("lambda %g: score fell from %g to %g at k=%d", lambda, previous, score, k)
+			}
+			previous = score
+		}
+	}
+}
+
+func TestPoissonTailScoreFallsWithExpected(t *testing.T) {
+	previous := math.Inf(1)
+	for _, lambda := range []float64{0.1, 1, 2, 5, 10, 20} {
+		score := poissonTailScore(15, lambda)
+		if score > previous {
+			t.Fatalf("score rose from %g to %g at lambda=%g", previous, score, lambda)
+		}
+		previous = score
+	}
+}
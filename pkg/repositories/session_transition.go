@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"gorm.io/gorm"
+)
+
+const transitionColumns = "session_id, player_id, world, login, bucket, at, uncertain"
+
+type SessionTransitionRepository struct{}
+
+func NewSessionTransitionRepository() *SessionTransitionRepository {
+	return &SessionTransitionRepository{}
+}
+
+// transitionSelect selects the logins (login true) or logouts of online
+// sessions as session_transitions rows. Callers append further conditions.
+func transitionSelect(login bool) string {
+	column, uncertain := "logout_at", "logout_uncertain"
+	if login {
+		column, uncertain = "login_at", "login_uncertain"
+	}
+
+	return fmt.Sprintf(`
+		SELECT s.id, s.player_id, s.world, %t,
+			FLOOR(EXTRACT(EPOCH FROM s.%s) / %d)::bigint, s.%s, s.%s
+		FROM online_sessions s
+		WHERE s.%s IS NOT NULL`,
+		login, column, database.TransitionBucketSeconds, column, uncertain, column)
+}
+
+// indexSessions adds the logins (login true) or logouts of the given
+// sessions to the transition index.
+func indexSessions(tx *gorm.DB, sessionIDs []uint, login bool) error {
+	for start := 0; start < len(sessionIDs); start += upsertBatchSize {
+		end := min(start+upsertBatchSize, len(sessionIDs))
+
+		err := tx.Exec(
+			"INSERT INTO session_transitions ("+transitionColumns+")"+transitionSelect(login)+" AND s.id IN ?",
+			sessionIDs[start:end],
+		).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IndexWorld adds every login and logout of a world's sessions that is not
+// in the transition index yet, and returns how many were added.
+func (r *SessionTransitionRepository) IndexWorld(world string) (int64, error) {
+	query := "INSERT INTO session_transitions (" + transitionColumns + ")" +
+		transitionSelect(true) + ` AND s.world = @world AND NOT EXISTS (
+			SELECT 1 FROM session_transitions t WHERE t.session_id = s.id AND t.login
+		)
+		UNION ALL` +
+		transitionSelect(false) + ` AND s.world = @world AND NOT EXISTS (
+			SELECT 1 FROM session_transitions t WHERE t.session_id = s.id AND NOT t.login
+		)`
+
+	result := database.DB.Exec(query, map[string]interface{}{"world": world})
+	return result.RowsAffected, result.Error
+}
+
+// Backfill indexes the sessions recorded before the transition index
+// existed. It does nothing once the index has any rows, since sessions are
+// indexed as they are written from then on.
+func (r *SessionTransitionRepository) Backfill() (int64, error) {
+	var indexed bool
+	if err := database.DB.Raw("SELECT EXISTS (SELECT 1 FROM session_transitions)").Scan(&indexed).Error; err != nil {
+		return 0, err
+	}
+	if indexed {
+		return 0, nil
+	}
+
+	var worlds []string
+	if err := database.DB.Model(&database.OnlineSession{}).Distinct().Pluck("world", &worlds).Error; err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, world := range worlds {
+		added, err := r.IndexWorld(world)
+		if err != nil {
+			return total, err
+		}
+		total += added
+	}
+	return total, nil
+}
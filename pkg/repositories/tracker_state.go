@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"gorm.io/gorm/clause"
+)
+
+type TrackerStateRepository struct{}
+
+func NewTrackerStateRepository() *TrackerStateRepository {
+	return &TrackerStateRepository{}
+}
+
+// Get returns the saved state for world, or nil if the world has never been
+// polled.
+func (r *TrackerStateRepository) Get(world string) (*database.TrackerState, error) {
+	var states []database.TrackerState
+	if err := database.DB.Where("world = ?", world).Limit(1).Find(&states).Error; err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
+
+func (r *TrackerStateRepository) Save(world string, lastPollAt time.Time) error {
+	state := database.TrackerState{
+		World:      world,
+		LastPollAt: lastPollAt,
+	}
+
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "world"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_poll_at", "updated_at"}),
+	}).Create(&state).Error
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/repositories"
+)
+
+const (
+	DefaultScanWindowSeconds = 60
+	DefaultScanMaxResults    = 20
+
+	// Confidence bands on the scan score, -log10 of the chance that the
+	// transitions are a coincidence: 6 is one in a million.
+	DefaultScanVeryHighScore = 6.0
+	DefaultScanHighScore     = 4.0
+	DefaultScanMediumScore   = 2.0
+)
+
+const (
+	ConfidenceVeryHigh = "very_high"
+	ConfidenceHigh     = "high"
+	ConfidenceMedium   = "medium"
+	ConfidenceLow      = "low"
+)
+
+// confidenceRanks orders the confidence levels from lowest to highest.
+var confidenceRanks = map[string]int{
+	ConfidenceLow:      0,
+	ConfidenceMedium:   1,
+	ConfidenceHigh:     2,
+	ConfidenceVeryHigh: 3,
+}
+
+// ConfidenceRank returns the position of level in the confidence order, or
+// -1 for an unknown level.
+func ConfidenceRank(level string) int {
+	if rank, ok := confidenceRanks[level]; ok {
+		return rank
+	}
+	return -1
+}
+
+// ScanSettings are the parameters of one scan, after applying the guild's
+// defaults and any per-scan overrides.
+type ScanSettings struct {
+	Options       repositories.ScanOptions
+	VeryHighScore float64
+	HighScore     float64
+	MediumScore   float64
+}
+
+// Confidence returns the confidence level of a scan score.
+func (s ScanSettings) Confidence(score float64) string {
+	switch {
+	case score >= s.VeryHighScore:
+		return ConfidenceVeryHigh
+	case score >= s.HighScore:
+		return ConfidenceHigh
+	case score >= s.MediumScore:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+// GuildScanSettings returns the scan settings configured for a guild, with
+// the built-in defaults filling in anything left unset.
+func GuildScanSettings(guildID string) ScanSettings {
+	settings := ScanSettings{
+		Options: repositories.ScanOptions{
+			AdjacentWindowSeconds: DefaultScanWindowSeconds,
+			MaxResults:            DefaultScanMaxResults,
+			GuildID:               guildID,
+		},
+		VeryHighScore: DefaultScanVeryHighScore,
+		HighScore:     DefaultScanHighScore,
+		MediumScore:   DefaultScanMediumScore,
+	}
+
+	config, err := repositories.NewGuildConfigRepository().FindByGuildID(guildID)
+	if err != nil {
+		return settings
+	}
+
+	if config.ScanWindowSeconds > 0 {
+		settings.Options.AdjacentWindowSeconds = config.ScanWindowSeconds
+	}
+	if config.ScanMaxResults > 0 {
+		settings.Options.MaxResults = config.ScanMaxResults
+	}
+	if config.ScanMinLevel > 0 {
+		settings.Options.MinLevel = config.ScanMinLevel
+	}
+	if config.ScanLookbackDays > 0 {
+		from := time.Now().AddDate(0, 0, -config.ScanLookbackDays)
+		settings.Options.From = &from
+	}
+	if config.ScanVeryHighScore > 0 {
+		settings.VeryHighScore = config.ScanVeryHighScore
+	}
+	if config.ScanHighScore > 0 {
+		settings.HighScore = config.ScanHighScore
+	}
+	if config.ScanMediumScore > 0 {
+		settings.MediumScore = config.ScanMediumScore
+	}
+
+	return settings
+}
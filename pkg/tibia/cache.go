@@ -0,0 +1,168 @@
+package tibia
+
+import (
+	"container/list"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheEntry is a cached API response body together with the validators
+// needed to revalidate it once it expires.
+type CacheEntry struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	ExpiresAt    time.Time
+}
+
+func (e *CacheEntry) Fresh(now time.Time) bool {
+	return now.Before(e.ExpiresAt)
+}
+
+// Cache stores API responses by request URL. Get returns expired entries as
+// well so their validators can be used for conditional requests.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+}
+
+// endpointTTLs are the default lifetimes used when the API does not send a
+// Cache-Control max-age.
+var endpointTTLs = map[string]time.Duration{
+	"characters":  25 * time.Second,
+	"guilds":      5 * time.Minute,
+	"powergamers": 1 * time.Minute,
+	"whoisonline": 5 * time.Second,
+}
+
+const defaultTTL = 30 * time.Second
+
+// responseTTL returns how long a response may be served from cache, and
+// false when the response must not be stored at all.
+func responseTTL(endpoint string, header http.Header) (time.Duration, bool) {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+		switch {
+		case directive == "no-store":
+			return 0, false
+		case directive == "no-cache":
+			return 0, true
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				return time.Duration(seconds) * time.Second, true
+			}
+		}
+	}
+
+	if ttl, ok := endpointTTLs[endpoint]; ok {
+		return ttl, true
+	}
+	return defaultTTL, true
+}
+
+type CacheStats struct {
+	Hits          uint64
+	Misses        uint64
+	Revalidations uint64
+}
+
+type cacheCounters struct {
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	revalidations atomic.Uint64
+}
+
+func (c *cacheCounters) snapshot() CacheStats {
+	return CacheStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Revalidations: c.revalidations.Load(),
+	}
+}
+
+// MemoryCache is a fixed-capacity LRU cache.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*memoryCacheItem).entry, true
+}
+
+func (c *MemoryCache) Set(key string, entry *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*memoryCacheItem).entry = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+// TieredCache reads through a fast cache to a slower, shared one (for
+// example Postgres) and writes to both.
+type TieredCache struct {
+	front Cache
+	back  Cache
+}
+
+func NewTieredCache(front, back Cache) *TieredCache {
+	return &TieredCache{front: front, back: back}
+}
+
+func (c *TieredCache) Get(key string) (*CacheEntry, bool) {
+	if entry, ok := c.front.Get(key); ok {
+		return entry, true
+	}
+
+	entry, ok := c.back.Get(key)
+	if ok {
+		c.front.Set(key, entry)
+	}
+	return entry, ok
+}
+
+func (c *TieredCache) Set(key string, entry *CacheEntry) {
+	c.front.Set(key, entry)
+	c.back.Set(key, entry)
+}
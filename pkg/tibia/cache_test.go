@@ -0,0 +1,81 @@
+package tibia
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryCache(2)
+
+	cache.Set("a", &CacheEntry{ETag: "a"})
+	cache.Set("b", &CacheEntry{ETag: "b"})
+
+	// Reading a makes b the least recently used
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("a missing")
+	}
+	cache.Set("c", &CacheEntry{ETag: "c"})
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if entry, ok := cache.Get(key); !ok || entry.ETag != key {
+			t.Errorf("Get(%q) = %v, %v", key, entry, ok)
+		}
+	}
+
+	// Replacing an entry does not grow the cache
+	cache.Set("a", &CacheEntry{ETag: "a2"})
+	if entry, _ := cache.Get("a"); entry.ETag != "a2" {
+		t.Errorf("a = %q, want a2", entry.ETag)
+	}
+	if _, ok := cache.Get("c"); !ok {
+		t.Error("c should still be cached")
+	}
+}
+
+func TestCacheEntryFresh(t *testing.T) {
+	now := time.Now()
+	entry := &CacheEntry{ExpiresAt: now.Add(time.Second)}
+
+	if !entry.Fresh(now) {
+		t.Error("entry should be fresh before it expires")
+	}
+	if entry.Fresh(now.Add(time.Second)) {
+		t.Error("entry should be stale once it expires")
+	}
+}
+
+func TestResponseTTL(t *testing.T) {
+	tests := []struct {
+		name          string
+		endpoint      string
+		cacheControl  string
+		wantTTL       time.Duration
+		wantCacheable bool
+	}{
+		{name: "endpoint default", endpoint: "guilds", wantTTL: 5 * time.Minute, wantCacheable: true},
+		{name: "unknown endpoint", endpoint: "houses", wantTTL: defaultTTL, wantCacheable: true},
+		{name: "max-age", endpoint: "guilds", cacheControl: "public, max-age=12", wantTTL: 12 * time.Second, wantCacheable: true},
+		{name: "no-cache", endpoint: "characters", cacheControl: "no-cache", wantTTL: 0, wantCacheable: true},
+		{name: "no-store", endpoint: "characters", cacheControl: "No-Store", wantTTL: 0, wantCacheable: false},
+		{name: "invalid max-age", endpoint: "characters", cacheControl: "max-age=soon", wantTTL: 25 * time.Second, wantCacheable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.cacheControl != "" {
+				header.Set("Cache-Control", tt.cacheControl)
+			}
+
+			ttl, cacheable := responseTTL(tt.endpoint, header)
+			if ttl != tt.wantTTL || cacheable != tt.wantCacheable {
+				t.Errorf("responseTTL() = %s, %v, want %s, %v", ttl, cacheable, tt.wantTTL, tt.wantCacheable)
+			}
+		})
+	}
+}
@@ -1,38 +1,271 @@
 package tibia
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
+const (
+	defaultCacheSize         = 5000
+	defaultRequestsPerSecond = 5
+	defaultBurst             = 10
+	maxRetries               = 3
+	retryBaseDelay           = 500 * time.Millisecond
+	retryMaxDelay            = 30 * time.Second
+	breakerThreshold         = 5
+	breakerCooldown          = 30 * time.Second
+	breakerMaxCooldown       = 10 * time.Minute
+)
+
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
+	cache      Cache
+	counters   cacheCounters
+	limiter    *RateLimiter
+	breaker    *CircuitBreaker
+}
+
+type ClientOptions struct {
+	Cache             Cache
+	RequestsPerSecond float64
+	Burst             int
 }
 
+// NewClient returns a client with a private cache and rate limiter.
+// Long-lived processes should build one client with NewClientWithOptions
+// and share it so every caller draws from the same limits.
 func NewClient(baseURL string) *Client {
+	return NewClientWithOptions(baseURL, ClientOptions{})
+}
+
+func NewClientWithOptions(baseURL string, opts ClientOptions) *Client {
+	if opts.Cache == nil {
+		opts.Cache = NewMemoryCache(defaultCacheSize)
+	}
+	if opts.RequestsPerSecond <= 0 {
+		opts.RequestsPerSecond = defaultRequestsPerSecond
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = defaultBurst
+	}
+
 	return &Client{
 		httpClient: &http.Client{
 			Timeout: 45 * time.Second,
 		},
 		baseURL: baseURL,
+		cache:   opts.Cache,
+		limiter: NewRateLimiter(opts.RequestsPerSecond, opts.Burst),
+		breaker: NewCircuitBreaker(breakerThreshold, breakerCooldown, breakerMaxCooldown),
 	}
 }
 
+// CacheStats returns the cache hit, miss and revalidation counts since the
+// client was created.
+func (c *Client) CacheStats() CacheStats {
+	return c.counters.snapshot()
+}
+
+// getJSON fetches reqURL and decodes the body into out, serving fresh responses
+// from the cache and revalidating stale ones with If-None-Match and
+// If-Modified-Since. endpoint selects the default TTL.
+func (c *Client) getJSON(ctx context.Context, endpoint, reqURL string, out interface{}) error {
+	cached, hasCached := c.cache.Get(reqURL)
+	if hasCached && cached.Fresh(time.Now()) {
+		c.counters.hits.Add(1)
+		return decodeBody(cached.Body, out)
+	}
+
+	resp, body, err := c.fetch(ctx, reqURL, cached)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		c.counters.revalidations.Add(1)
+		if ttl, cacheable := responseTTL(endpoint, resp.Header); cacheable {
+			c.cache.Set(reqURL, &CacheEntry{
+				Body:         cached.Body,
+				ETag:         cached.ETag,
+				LastModified: cached.LastModified,
+				ExpiresAt:    now.Add(ttl),
+			})
+		}
+		return decodeBody(cached.Body, out)
+	}
+
+	c.counters.misses.Add(1)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	if err := decodeBody(body, out); err != nil {
+		return err
+	}
+
+	if ttl, cacheable := responseTTL(endpoint, resp.Header); cacheable {
+		c.cache.Set(reqURL, &CacheEntry{
+			Body:         body,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			ExpiresAt:    now.Add(ttl),
+		})
+	}
+
+	return nil
+}
+
+// fetch performs the HTTP request through the rate limiter and circuit
+// breaker, retrying network errors, 429s and 5xx responses with backoff.
+// The returned body has already been read and the response closed.
+func (c *Client) fetch(ctx context.Context, reqURL string, cached *CacheEntry) (*http.Response, []byte, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		allowed, probe := c.breaker.Allow()
+		if !allowed {
+			return nil, nil, ErrCircuitOpen
+		}
+
+		if err := c.limiter.Wait(ctx); err != nil {
+			c.breaker.Cancel(probe)
+			return nil, nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			c.breaker.Cancel(probe)
+			return nil, nil, err
+		}
+
+		if cached != nil {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+
+		var header http.Header
+		resp, err := c.httpClient.Do(req)
+		if err == nil {
+			var body []byte
+			body, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if err == nil && !retryable(resp.StatusCode) {
+				c.breaker.Success()
+				return resp, body, nil
+			}
+			if err == nil {
+				err = fmt.Errorf("API returned status %d", resp.StatusCode)
+			}
+			header = resp.Header
+		}
+
+		if ctx.Err() != nil {
+			c.breaker.Cancel(probe)
+			return nil, nil, ctx.Err()
+		}
+
+		c.breaker.Failure(probe)
+		lastErr = err
+
+		if attempt == maxRetries {
+			break
+		}
+
+		timer := time.NewTimer(backoff(attempt, retryBaseDelay, retryMaxDelay, header))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return nil, nil, lastErr
+}
+
+func decodeBody(body []byte, out interface{}) error {
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
 type Character struct {
-	Name      string `json:"name"`
-	Sex       string `json:"sex"`
-	Vocation  string `json:"vocation"`
-	Level     int    `json:"level"`
-	Residence string `json:"residence"`
-	Guild     string `json:"guild"`
-	GuildRank string `json:"guild_rank"`
-	GuildURL  string `json:"guild_url"`
-	LastLogin string `json:"last_login"`
-	IsPremium bool   `json:"is_premium"`
-	Country   string `json:"country"`
+	Name      string  `json:"name"`
+	Sex       string  `json:"sex"`
+	Vocation  string  `json:"vocation"`
+	Level     int     `json:"level"`
+	Residence string  `json:"residence"`
+	Guild     string  `json:"guild"`
+	GuildRank string  `json:"guild_rank"`
+	GuildURL  string  `json:"guild_url"`
+	LastLogin string  `json:"last_login"`
+	IsPremium bool    `json:"is_premium"`
+	Country   string  `json:"country"`
+	Deaths    []Death `json:"deaths"`
+	// FormerNames lists the names the character had before being renamed.
+	FormerNames []string `json:"former_names"`
+}
+
+// WasNamed reports whether name is one of the character's former names,
+// compared case-insensitively.
+func (c *Character) WasNamed(name string) bool {
+	for _, former := range c.FormerNames {
+		if strings.EqualFold(strings.TrimSpace(former), strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+type Killer struct {
+	Name   string `json:"name"`
+	Player bool   `json:"player"`
+	Traded bool   `json:"traded"`
+	Summon string `json:"summon"`
+}
+
+type Death struct {
+	Time    string   `json:"time"`
+	Level   int      `json:"level"`
+	Reason  string   `json:"reason"`
+	Killers []Killer `json:"killers"`
+	Assists []Killer `json:"assists"`
+}
+
+// IsPvP reports whether any killer or assist in the death was a player.
+func (d Death) IsPvP() bool {
+	for _, k := range d.Killers {
+		if k.Player {
+			return true
+		}
+	}
+	for _, k := range d.Assists {
+		if k.Player {
+			return true
+		}
+	}
+	return false
+}
+
+// OccurredAt parses the death time returned by the API.
+func (d Death) OccurredAt() (time.Time, error) {
+	return time.Parse(time.RFC3339, d.Time)
 }
 
 type GuildMember struct {
@@ -74,48 +307,43 @@ type WhosOnlineResponse struct {
 }
 
 func (c *Client) GetCharacter(name string) (*Character, error) {
-	url := fmt.Sprintf("%s/characters/%s", c.baseURL, name)
-
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch character: %w", err)
-	}
-	defer resp.Body.Close()
+	return c.GetCharacterContext(context.Background(), name)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
+// GetCharacterContext is GetCharacter bounded by ctx.
+func (c *Client) GetCharacterContext(ctx context.Context, name string) (*Character, error) {
+	reqURL := fmt.Sprintf("%s/characters/%s", c.baseURL, url.PathEscape(name))
 
 	var character Character
-	if err := json.NewDecoder(resp.Body).Decode(&character); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, "characters", reqURL, &character); err != nil {
+		return nil, fmt.Errorf("failed to fetch character: %w", err)
 	}
 
 	return &character, nil
 }
 
 func (c *Client) GetGuildMembers(guildID int) (*GuildResponse, error) {
-	url := fmt.Sprintf("%s/guilds/%d", c.baseURL, guildID)
-
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch guild: %w", err)
-	}
-	defer resp.Body.Close()
+	return c.GetGuildMembersContext(context.Background(), guildID)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
+// GetGuildMembersContext is GetGuildMembers bounded by ctx.
+func (c *Client) GetGuildMembersContext(ctx context.Context, guildID int) (*GuildResponse, error) {
+	reqURL := fmt.Sprintf("%s/guilds/%d", c.baseURL, guildID)
 
 	var guild GuildResponse
-	if err := json.NewDecoder(resp.Body).Decode(&guild); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, "guilds", reqURL, &guild); err != nil {
+		return nil, fmt.Errorf("failed to fetch guild: %w", err)
 	}
 
 	return &guild, nil
 }
 
 func (c *Client) GetPowergamers(list, vocation string, includeAll bool) ([]Powergamer, error) {
+	return c.GetPowergamersContext(context.Background(), list, vocation, includeAll)
+}
+
+// GetPowergamersContext is GetPowergamers bounded by ctx.
+func (c *Client) GetPowergamersContext(ctx context.Context, list, vocation string, includeAll bool) ([]Powergamer, error) {
 	if list == "" {
 		list = "today"
 	}
@@ -125,42 +353,41 @@ func (c *Client) GetPowergamers(list, vocation string, includeAll bool) ([]Power
 		includeAllStr = "true"
 	}
 
-	url := fmt.Sprintf("%s/powergamers?list=%s&include_all=%s&vocation=%s", c.baseURL, list, includeAllStr, vocation)
-
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch powergamers: %w", err)
-	}
-	defer resp.Body.Close()
+	query := url.Values{}
+	query.Set("list", list)
+	query.Set("include_all", includeAllStr)
+	query.Set("vocation", vocation)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
+	reqURL := fmt.Sprintf("%s/powergamers?%s", c.baseURL, query.Encode())
 
 	var response PowergamersResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, "powergamers", reqURL, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch powergamers: %w", err)
 	}
 
 	return response.Powergamers, nil
 }
 
 func (c *Client) GetWhosOnline() (*WhosOnlineResponse, error) {
-	url := fmt.Sprintf("%s/whoisonline", c.baseURL)
+	return c.GetWhosOnlineContext(context.Background())
+}
 
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch whos online: %w", err)
-	}
-	defer resp.Body.Close()
+// GetWhosOnlineContext is GetWhosOnline bounded by ctx.
+func (c *Client) GetWhosOnlineContext(ctx context.Context) (*WhosOnlineResponse, error) {
+	return c.GetWhosOnlineWorldContext(ctx, "")
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+// GetWhosOnlineWorldContext returns the players online in world. An empty
+// world leaves the choice to the API deployment.
+func (c *Client) GetWhosOnlineWorldContext(ctx context.Context, world string) (*WhosOnlineResponse, error) {
+	reqURL := fmt.Sprintf("%s/whoisonline", c.baseURL)
+	if world != "" {
+		reqURL = fmt.Sprintf("%s?world=%s", reqURL, url.QueryEscape(world))
 	}
 
 	var response WhosOnlineResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, "whoisonline", reqURL, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch whos online: %w", err)
 	}
 
 	return &response, nil
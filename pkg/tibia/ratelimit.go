@@ -0,0 +1,183 @@
+package tibia
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/logger"
+)
+
+// RateLimiter is a token bucket shared by every caller of a Client.
+type RateLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:     perSecond,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		lastFill: time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.lastFill).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.lastFill = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// ErrCircuitOpen is returned without contacting the API while the circuit
+// breaker considers it down.
+var ErrCircuitOpen = errors.New("tibia API circuit breaker is open")
+
+// CircuitBreaker stops requests after repeated failures and lets a single
+// probe through once the cooldown has passed.
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	threshold   int
+	cooldown    time.Duration
+	maxCooldown time.Duration
+	failures    int
+	openUntil   time.Time
+	open        bool
+	probing     bool
+	currentWait time.Duration
+}
+
+func NewCircuitBreaker(threshold int, cooldown, maxCooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold:   threshold,
+		cooldown:    cooldown,
+		maxCooldown: maxCooldown,
+		currentWait: cooldown,
+	}
+}
+
+// Allow reports whether a request may be attempted now, and whether that
+// request is the half-open probe. Outcomes are reported with that flag, so
+// only the probe can reopen the breaker or release its slot.
+func (b *CircuitBreaker) Allow() (allowed, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true, false
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.open {
+		logger.Success("Tibia API recovered, resuming requests")
+	}
+	b.failures = 0
+	b.open = false
+	b.probing = false
+	b.currentWait = b.cooldown
+}
+
+func (b *CircuitBreaker) Failure(probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.open {
+		// Late failures of requests sent before the breaker opened say
+		// nothing new about the API.
+		if !probe {
+			return
+		}
+
+		// The probe failed: back off further before the next one.
+		b.probing = false
+		b.currentWait *= 2
+		if b.currentWait > b.maxCooldown {
+			b.currentWait = b.maxCooldown
+		}
+		b.openUntil = time.Now().Add(b.currentWait)
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.open = true
+		b.openUntil = time.Now().Add(b.currentWait)
+		logger.Warn("Tibia API failed %d times in a row, pausing requests for %s", b.failures, b.currentWait)
+	}
+}
+
+// Cancel releases a request let through by Allow that ended without an
+// outcome, such as a cancelled context. If it was the probe, the next
+// request may probe instead.
+func (b *CircuitBreaker) Cancel(probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+}
+
+// retryable reports whether a response status is worth retrying.
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// backoff returns the delay before retry attempt n (starting at 0), using
+// exponential growth with full jitter. A Retry-After header takes precedence.
+func backoff(attempt int, base, max time.Duration, header http.Header) time.Duration {
+	if header != nil {
+		if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	delay := base << attempt
+	if delay > max || delay <= 0 {
+		delay = max
+	}
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
@@ -0,0 +1,228 @@
+package tibia
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	const (
+		allow   = "allow"
+		fail    = "fail"
+		succeed = "succeed"
+		cancel  = "cancel"
+		// lateFail and lateCancel report requests let through before the
+		// breaker opened, which are not the probe.
+		lateFail   = "late-fail"
+		lateCancel = "late-cancel"
+		// expire skips to the end of the current cooldown.
+		expire = "expire"
+	)
+
+	type step struct {
+		op   string
+		want bool // for allow
+	}
+
+	tests := []struct {
+		name     string
+		steps    []step
+		wantWait time.Duration
+	}{
+		{
+			name:     "stays closed below the threshold",
+			steps:    []step{{op: fail}, {op: fail}, {op: allow, want: true}},
+			wantWait: time.Second,
+		},
+		{
+			name:     "success resets the failure count",
+			steps:    []step{{op: fail}, {op: fail}, {op: succeed}, {op: fail}, {op: fail}, {op: allow, want: true}},
+			wantWait: time.Second,
+		},
+		{
+			name:     "opens at the threshold",
+			steps:    []step{{op: fail}, {op: fail}, {op: fail}, {op: allow, want: false}},
+			wantWait: time.Second,
+		},
+		{
+			name: "lets a single probe through after the cooldown",
+			steps: []step{
+				{op: fail}, {op: fail}, {op: fail}, {op: expire},
+				{op: allow, want: true}, {op: allow, want: false},
+			},
+			wantWait: time.Second,
+		},
+		{
+			name: "closes when the probe succeeds",
+			steps: []step{
+				{op: fail}, {op: fail}, {op: fail}, {op: expire},
+				{op: allow, want: true}, {op: succeed}, {op: allow, want: true}, {op: allow, want: true},
+			},
+			wantWait: time.Second,
+		},
+		{
+			name: "backs off further when the probe fails",
+			steps: []step{
+				{op: fail}, {op: fail}, {op: fail}, {op: expire},
+				{op: allow, want: true}, {op: fail}, {op: allow, want: false},
+				{op: expire}, {op: allow, want: true}, {op: fail},
+			},
+			wantWait: 4 * time.Second,
+		},
+		{
+			name: "caps the cooldown",
+			steps: []step{
+				{op: fail}, {op: fail}, {op: fail},
+				{op: expire}, {op: allow, want: true}, {op: fail},
+				{op: expire}, {op: allow, want: true}, {op: fail},
+				{op: expire}, {op: allow, want: true}, {op: fail},
+				{op: expire}, {op: allow, want: true}, {op: fail},
+			},
+			wantWait: 5 * time.Second,
+		},
+		{
+			name: "late failures do not extend the cooldown",
+			steps: []step{
+				{op: fail}, {op: fail}, {op: fail},
+				{op: lateFail}, {op: lateFail}, {op: lateFail}, {op: allow, want: false},
+			},
+			wantWait: time.Second,
+		},
+		{
+			name: "a late cancel does not release the probe",
+			steps: []step{
+				{op: fail}, {op: fail}, {op: fail}, {op: expire},
+				{op: allow, want: true}, {op: lateCancel}, {op: allow, want: false},
+			},
+			wantWait: time.Second,
+		},
+		{
+			name: "a cancelled probe lets the next request probe",
+			steps: []step{
+				{op: fail}, {op: fail}, {op: fail}, {op: expire},
+				{op: allow, want: true}, {op: cancel}, {op: allow, want: true}, {op: allow, want: false},
+			},
+			wantWait: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(3, time.Second, 5*time.Second)
+
+			// probe is whether the last allowed request was the probe; the
+			// outcome steps report for that request.
+			probe := false
+			for i, s := range tt.steps {
+				switch s.op {
+				case allow:
+					var got bool
+					got, probe = breaker.Allow()
+					if got != s.want {
+						t.Fatalf("step %d: Allow() = %v, want %v", i, got, s.want)
+					}
+				case fail:
+					breaker.Failure(probe)
+				case lateFail:
+					breaker.Failure(false)
+				case succeed:
+					breaker.Success()
+				case cancel:
+					breaker.Cancel(probe)
+				case lateCancel:
+					breaker.Cancel(false)
+				case expire:
+					breaker.openUntil = time.Now().Add(-time.Millisecond)
+				}
+			}
+
+			if breaker.currentWait != tt.wantWait {
+				t.Errorf("cooldown = %s, want %s", breaker.currentWait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusNotModified:         false,
+		http.StatusBadRequest:          false,
+		http.StatusNotFound:            false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+	}
+
+	for status, want := range tests {
+		if got := retryable(status); got != want {
+			t.Errorf("retryable(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 100*time.Millisecond, time.Second
+
+	tests := []struct {
+		name     string
+		attempt  int
+		header   http.Header
+		wantMax  time.Duration
+		wantOnly time.Duration
+	}{
+		{name: "first retry", attempt: 0, wantMax: base},
+		{name: "grows exponentially", attempt: 2, wantMax: 4 * base},
+		{name: "capped", attempt: 10, wantMax: max},
+		{name: "shift overflow", attempt: 80, wantMax: max},
+		{name: "retry-after wins", attempt: 0, header: http.Header{"Retry-After": {"7"}}, wantOnly: 7 * time.Second},
+		{name: "invalid retry-after", attempt: 1, header: http.Header{"Retry-After": {"soon"}}, wantMax: 2 * base},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				delay := backoff(tt.attempt, base, max, tt.header)
+				if tt.wantOnly > 0 {
+					if delay != tt.wantOnly {
+						t.Fatalf("backoff = %s, want %s", delay, tt.wantOnly)
+					}
+					continue
+				}
+				if delay <= 0 || delay > tt.wantMax {
+					t.Fatalf("backoff = %s, want within (0, %s]", delay, tt.wantMax)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(1000, 3)
+
+	// The burst is available at once
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("burst took %s", elapsed)
+	}
+
+	// Past the burst, callers wait for tokens and give up with their context
+	slow := NewRateLimiter(0.01, 1)
+	if err := slow.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := slow.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
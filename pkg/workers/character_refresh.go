@@ -0,0 +1,181 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/tibia"
+)
+
+const characterRefreshWorkerName = "character-refresh"
+
+// characterDiffer compares a freshly fetched character against the state
+// stored in an item's metadata. It records the new state in metadata and
+// returns the events describing what changed, plus whether metadata was
+// modified and needs saving.
+type characterDiffer func(list *database.List, item *database.ListItem, character *tibia.Character, metadata map[string]interface{}) ([]Event, bool)
+
+// characterDiffers maps each list type that depends on character data to
+// the differ producing its change events.
+var characterDiffers = map[string]characterDiffer{
+	"premium-alerts":   diffPremium,
+	"residence-change": diffResidence,
+	"death-alerts":     diffDeaths,
+	"guild-change":     diffGuild,
+}
+
+type characterWatch struct {
+	list   database.List
+	item   database.ListItem
+	differ characterDiffer
+}
+
+// CharacterRefreshWorker fetches every distinct character watched by any
+// list once per cycle and publishes the resulting change events on the bus,
+// so a character present on several lists costs a single API request.
+type CharacterRefreshWorker struct {
+	listRepo     *repositories.ListRepository
+	itemRepo     *repositories.ListItemRepository
+	tibiaClient  *tibia.Client
+	bus          *EventBus
+	pollInterval time.Duration
+}
+
+func NewCharacterRefreshWorker(tibiaClient *tibia.Client, bus *EventBus) *CharacterRefreshWorker {
+	return &CharacterRefreshWorker{
+		listRepo:     repositories.NewListRepository(),
+		itemRepo:     repositories.NewListItemRepository(),
+		tibiaClient:  tibiaClient,
+		bus:          bus,
+		pollInterval: 30 * time.Second,
+	}
+}
+
+func (w *CharacterRefreshWorker) Name() string {
+	return characterRefreshWorkerName
+}
+
+func (w *CharacterRefreshWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	w.refreshCharacters(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.refreshCharacters(ctx)
+		}
+	}
+}
+
+// collectWatches groups the items of every character-based list by
+// normalized character name.
+func (w *CharacterRefreshWorker) collectWatches() map[string][]characterWatch {
+	watches := make(map[string][]characterWatch)
+
+	for listType, differ := range characterDiffers {
+		lists, err := w.listRepo.FindByType(listType)
+		if err != nil {
+			logger.Worker(characterRefreshWorkerName, "Error fetching %s lists: %v", listType, err)
+			continue
+		}
+
+		for _, list := range lists {
+			items, err := w.itemRepo.FindByListID(list.ID)
+			if err != nil {
+				logger.Worker(characterRefreshWorkerName, "Error fetching items for list %d: %v", list.ID, err)
+				continue
+			}
+
+			for _, item := range items {
+				if isGuildWatch(&item) {
+					continue
+				}
+				key := strings.ToLower(strings.TrimSpace(item.Name))
+				watches[key] = append(watches[key], characterWatch{
+					list:   list,
+					item:   item,
+					differ: differ,
+				})
+			}
+		}
+	}
+
+	return watches
+}
+
+func (w *CharacterRefreshWorker) refreshCharacters(ctx context.Context) {
+	watches := w.collectWatches()
+
+	logger.Worker(characterRefreshWorkerName, "Refreshing %d characters", len(watches))
+
+	for _, characterWatches := range watches {
+		if ctx.Err() != nil {
+			return
+		}
+
+		name := characterWatches[0].item.Name
+
+		character, err := w.tibiaClient.GetCharacterContext(ctx, name)
+		if errors.Is(err, tibia.ErrCircuitOpen) {
+			logger.Worker(characterRefreshWorkerName, "Tibia API unavailable, skipping the rest of this cycle")
+			break
+		}
+		if err != nil {
+			logger.Worker(characterRefreshWorkerName, "Error fetching character %s: %v", name, err)
+			continue
+		}
+
+		if !strings.EqualFold(character.Name, name) && character.WasNamed(name) {
+			logger.Worker(characterRefreshWorkerName, "%s was renamed to %s", name, character.Name)
+			w.bus.Publish(ctx, CharacterRenamedEvent{OldName: name, NewName: character.Name})
+			// The rename worker rewrites these items; saving them here could
+			// race with it, so they are picked up under the new name next cycle.
+			continue
+		}
+
+		for i := range characterWatches {
+			w.applyCharacter(ctx, &characterWatches[i], character)
+		}
+	}
+
+	stats := w.tibiaClient.CacheStats()
+	logger.Worker(characterRefreshWorkerName, "Tibia API cache: %d hits, %d misses, %d revalidated",
+		stats.Hits, stats.Misses, stats.Revalidations)
+}
+
+func (w *CharacterRefreshWorker) applyCharacter(ctx context.Context, watch *characterWatch, character *tibia.Character) {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(watch.item.Metadata, &metadata); err != nil {
+		metadata = make(map[string]interface{})
+	}
+
+	events, changed := watch.differ(&watch.list, &watch.item, character, metadata)
+
+	if changed {
+		metadataJSON, err := json.Marshal(metadata)
+		if err != nil {
+			logger.Error("Error encoding metadata: %v", err)
+			return
+		}
+
+		watch.item.Metadata = metadataJSON
+		if err := w.itemRepo.Update(&watch.item); err != nil {
+			logger.Error("Error updating item: %v", err)
+			return
+		}
+	}
+
+	for _, event := range events {
+		w.bus.Publish(ctx, event)
+	}
+}
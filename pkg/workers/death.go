@@ -0,0 +1,248 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/tibia"
+	"gorm.io/gorm"
+)
+
+const deathWorkerName = "death-alerts"
+
+type DeathWorker struct {
+	session  *discordgo.Session
+	itemRepo *repositories.ListItemRepository
+	events   <-chan Event
+}
+
+func NewDeathWorker(session *discordgo.Session, bus *EventBus) *DeathWorker {
+	return &DeathWorker{
+		session:  session,
+		itemRepo: repositories.NewListItemRepository(),
+		events:   bus.Subscribe(EventCharacterDied),
+	}
+}
+
+func (w *DeathWorker) Name() string {
+	return deathWorkerName
+}
+
+func (w *DeathWorker) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-w.events:
+			died, ok := event.(CharacterDiedEvent)
+			if !ok {
+				continue
+			}
+			w.deliver(&died)
+		}
+	}
+}
+
+// deliver announces the deaths of an event that are still newer than the
+// item's stored marker, advancing the marker after each one is sent. Events
+// are handled one at a time, so deaths published again before the marker
+// moved are skipped here. A failed send stops at that death; the refresh
+// stage publishes it again on its next pass.
+func (w *DeathWorker) deliver(died *CharacterDiedEvent) {
+	item, err := w.itemRepo.FindByID(died.Item.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+	if err != nil {
+		logger.Worker(deathWorkerName, "Error loading item %d: %v", died.Item.ID, err)
+		return
+	}
+
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(item.Metadata, &metadata); err != nil {
+		metadata = make(map[string]interface{})
+	}
+
+	lastDeathAt, ok, err := deathMarker(metadata)
+	if err != nil || !ok {
+		logger.Worker(deathWorkerName, "No valid death marker for %s, skipping delivery", item.Name)
+		return
+	}
+
+	for _, d := range died.Deaths {
+		if !d.At.After(lastDeathAt) {
+			continue
+		}
+
+		if err := w.sendNotification(&died.List, item, d.Death, d.At); err != nil {
+			logger.Worker(deathWorkerName, "Error sending death of %s, will retry: %v", item.Name, err)
+			return
+		}
+
+		if err := w.itemRepo.SetMetadataValue(item.ID, "last_death_at", d.At.Format(time.RFC3339)); err != nil {
+			logger.Worker(deathWorkerName, "Error saving death marker for %s: %v", item.Name, err)
+			return
+		}
+		lastDeathAt = d.At
+	}
+}
+
+// deathMarker returns the item's persisted "last_death_at" marker. ok is
+// false when no marker was recorded yet; an empty marker means the character
+// had no deaths when it was first checked.
+func deathMarker(metadata map[string]interface{}) (at time.Time, ok bool, err error) {
+	value, ok := metadata["last_death_at"].(string)
+	if !ok || value == "" {
+		return time.Time{}, ok, nil
+	}
+
+	at, err = time.Parse(time.RFC3339, value)
+	return at, true, err
+}
+
+// diffDeaths publishes the deaths newer than the item's persisted
+// "last_death_at" marker, oldest first. Only the first check writes the
+// marker; afterwards DeathWorker advances it as deaths are delivered.
+func diffDeaths(list *database.List, item *database.ListItem, character *tibia.Character, metadata map[string]interface{}) ([]Event, bool) {
+	deaths := make([]CharacterDeath, 0, len(character.Deaths))
+	for _, death := range character.Deaths {
+		at, err := death.OccurredAt()
+		if err != nil {
+			logger.Worker(deathWorkerName, "Skipping death of %s with invalid time %q", item.Name, death.Time)
+			continue
+		}
+		deaths = append(deaths, CharacterDeath{Death: death, At: at})
+	}
+
+	sort.Slice(deaths, func(a, b int) bool {
+		return deaths[a].At.Before(deaths[b].At)
+	})
+
+	lastDeathAt, hasLastDeath, err := deathMarker(metadata)
+	if err != nil {
+		logger.Worker(deathWorkerName, "Invalid stored death marker for %s: %v", item.Name, err)
+		return nil, false
+	}
+
+	// The first check only records where the history ends so that deaths
+	// from before the character was added are not announced.
+	if !hasLastDeath {
+		lastDeath := ""
+		if len(deaths) > 0 {
+			lastDeath = deaths[len(deaths)-1].At.Format(time.RFC3339)
+		}
+		metadata["last_death_at"] = lastDeath
+		logger.Worker(deathWorkerName, "Initial death marker for %s: %q", item.Name, lastDeath)
+		return nil, true
+	}
+
+	var newDeaths []CharacterDeath
+	for _, d := range deaths {
+		if d.At.After(lastDeathAt) {
+			newDeaths = append(newDeaths, d)
+		}
+	}
+	if len(newDeaths) == 0 {
+		return nil, false
+	}
+
+	logger.Worker(deathWorkerName, "%d new deaths for %s", len(newDeaths), item.Name)
+	return []Event{CharacterDiedEvent{
+		ItemEvent: ItemEvent{List: *list, Item: *item},
+		Deaths:    newDeaths,
+	}}, false
+}
+
+func formatKillers(killers []tibia.Killer) string {
+	if len(killers) == 0 {
+		return "Unknown"
+	}
+
+	names := make([]string, len(killers))
+	for i, k := range killers {
+		name := k.Name
+		if k.Summon != "" {
+			name = fmt.Sprintf("%s (summon of %s)", k.Summon, k.Name)
+		}
+		if k.Player {
+			name = fmt.Sprintf("**%s**", name)
+		}
+		names[i] = name
+	}
+	return strings.Join(names, ", ")
+}
+
+func (w *DeathWorker) sendNotification(list *database.List, item *database.ListItem, death tibia.Death, at time.Time) error {
+	color := 0x95A5A6
+	kind := "PvE"
+	if death.IsPvP() {
+		color = 0xC0392B
+		kind = "PvP"
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:   "Level",
+			Value:  fmt.Sprintf("%d", death.Level),
+			Inline: true,
+		},
+		{
+			Name:   "Type",
+			Value:  kind,
+			Inline: true,
+		},
+		{
+			Name:   "Time",
+			Value:  fmt.Sprintf("<t:%d:f>", at.Unix()),
+			Inline: true,
+		},
+		{
+			Name:  "Killers",
+			Value: formatKillers(death.Killers),
+		},
+	}
+
+	if len(death.Assists) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "Assists",
+			Value: formatKillers(death.Assists),
+		})
+	}
+
+	description := fmt.Sprintf("**%s** died at level %d", item.Name, death.Level)
+	if death.Reason != "" {
+		description = death.Reason
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("☠️ %s Died", item.Name),
+		Description: description,
+		Color:       color,
+		Fields:      fields,
+		Timestamp:   at.Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Death Alert",
+		},
+	}
+
+	content := ""
+	if list.NotifyEveryone {
+		content = "@everyone"
+	}
+
+	_, err := w.session.ChannelMessageSendComplex(list.ChannelID, &discordgo.MessageSend{
+		Content: content,
+		Embed:   embed,
+	})
+
+	return err
+}
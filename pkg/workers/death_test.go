@@ -0,0 +1,115 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/tibia"
+)
+
+func TestDiffDeaths(t *testing.T) {
+	character := &tibia.Character{
+		Name: "Bubble",
+		Deaths: []tibia.Death{
+			{Time: "2026-10-03T12:00:00Z", Level: 310},
+			{Time: "not a time", Level: 309},
+			{Time: "2026-10-01T08:30:00Z", Level: 305},
+			{Time: "2026-10-02T20:15:00Z", Level: 308},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		character   *tibia.Character
+		metadata    map[string]interface{}
+		wantDeaths  []string
+		wantChanged bool
+		wantMarker  interface{}
+	}{
+		{
+			name:        "first check records the latest death",
+			character:   character,
+			metadata:    map[string]interface{}{},
+			wantChanged: true,
+			wantMarker:  "2026-10-03T12:00:00Z",
+		},
+		{
+			name:        "first check without deaths",
+			character:   &tibia.Character{Name: "Bubble"},
+			metadata:    map[string]interface{}{},
+			wantChanged: true,
+			wantMarker:  "",
+		},
+		{
+			name:       "no deaths since the marker",
+			character:  character,
+			metadata:   map[string]interface{}{"last_death_at": "2026-10-03T12:00:00Z"},
+			wantMarker: "2026-10-03T12:00:00Z",
+		},
+		{
+			// The marker is left for DeathWorker to advance once delivered
+			name:       "new deaths oldest first",
+			character:  character,
+			metadata:   map[string]interface{}{"last_death_at": "2026-10-01T08:30:00Z"},
+			wantDeaths: []string{"2026-10-02T20:15:00Z", "2026-10-03T12:00:00Z"},
+			wantMarker: "2026-10-01T08:30:00Z",
+		},
+		{
+			name:       "empty marker announces every death",
+			character:  character,
+			metadata:   map[string]interface{}{"last_death_at": ""},
+			wantDeaths: []string{"2026-10-01T08:30:00Z", "2026-10-02T20:15:00Z", "2026-10-03T12:00:00Z"},
+			wantMarker: "",
+		},
+		{
+			name:       "invalid marker",
+			character:  character,
+			metadata:   map[string]interface{}{"last_death_at": "yesterday"},
+			wantMarker: "yesterday",
+		},
+	}
+
+	list := &database.List{ID: 1, Type: "death-alerts"}
+	item := &database.ListItem{ID: 2, ListID: 1, Name: "Bubble"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, changed := diffDeaths(list, item, tt.character, tt.metadata)
+
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if marker := tt.metadata["last_death_at"]; marker != tt.wantMarker {
+				t.Errorf("marker = %v, want %v", marker, tt.wantMarker)
+			}
+
+			if len(tt.wantDeaths) == 0 {
+				if len(events) != 0 {
+					t.Fatalf("got %d events, want none", len(events))
+				}
+				return
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want one", len(events))
+			}
+			died, ok := events[0].(CharacterDiedEvent)
+			if !ok {
+				t.Fatalf("got %T, want CharacterDiedEvent", events[0])
+			}
+			if died.Item.ID != item.ID {
+				t.Errorf("event item = %d, want %d", died.Item.ID, item.ID)
+			}
+
+			if len(died.Deaths) != len(tt.wantDeaths) {
+				t.Fatalf("got %d deaths, want %d", len(died.Deaths), len(tt.wantDeaths))
+			}
+			for i, want := range tt.wantDeaths {
+				if got := died.Deaths[i].At.Format(time.RFC3339); got != want {
+					t.Errorf("death %d at %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/tibia"
+)
+
+type EventType string
+
+const (
+	EventLogin            EventType = "login"
+	EventLogout           EventType = "logout"
+	EventPremiumChanged   EventType = "premium-changed"
+	EventResidenceChanged EventType = "residence-changed"
+	EventGuildChanged     EventType = "guild-changed"
+	EventCharacterDied    EventType = "character-died"
+	EventCharacterRenamed EventType = "character-renamed"
+)
+
+// Event is anything published on the EventBus.
+type Event interface {
+	EventType() EventType
+}
+
+// PresenceEvent is published by the online tracker whenever a character
+// appears in or disappears from the online list.
+type PresenceEvent struct {
+	Type     EventType
+	Name     string
+	World    string
+	PlayerID uint
+	Level    int
+	Vocation string
+	At       time.Time
+	// LoginAt is the start of the session that ended; only set on logout.
+	LoginAt *time.Time
+}
+
+func (e PresenceEvent) EventType() EventType { return e.Type }
+
+// ItemEvent identifies the list item a change event was detected for.
+type ItemEvent struct {
+	List database.List
+	Item database.ListItem
+}
+
+type PremiumChangedEvent struct {
+	ItemEvent
+	Old bool
+	New bool
+}
+
+func (PremiumChangedEvent) EventType() EventType { return EventPremiumChanged }
+
+type ResidenceChangedEvent struct {
+	ItemEvent
+	Old string
+	New string
+}
+
+func (ResidenceChangedEvent) EventType() EventType { return EventResidenceChanged }
+
+type GuildChangedEvent struct {
+	ItemEvent
+	OldGuild string
+	OldRank  string
+	NewGuild string
+	NewRank  string
+}
+
+func (GuildChangedEvent) EventType() EventType { return EventGuildChanged }
+
+// CharacterDiedEvent carries every death of a character newer than its
+// item's death marker, oldest first. The marker is only advanced once a
+// death has been delivered, so the same deaths may be published again until
+// then.
+type CharacterDiedEvent struct {
+	ItemEvent
+	Deaths []CharacterDeath
+}
+
+type CharacterDeath struct {
+	Death tibia.Death
+	At    time.Time
+}
+
+func (CharacterDiedEvent) EventType() EventType { return EventCharacterDied }
+
+// CharacterRenamedEvent is published when a watched name turns out to be a
+// former name of the character the API returns for it.
+type CharacterRenamedEvent struct {
+	OldName string
+	NewName string
+}
+
+func (CharacterRenamedEvent) EventType() EventType { return EventCharacterRenamed }
+
+// subscriberBuffer bounds how many events a slow subscriber can lag behind
+// before publishers wait for it.
+const subscriberBuffer = 256
+
+// EventBus fans events out to the workers subscribed to their type.
+// Publishing waits for slow subscribers rather than dropping events.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[EventType][]chan Event
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[EventType][]chan Event),
+	}
+}
+
+// Subscribe returns a single channel receiving every event of the given types.
+func (b *EventBus) Subscribe(types ...EventType) <-chan Event {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	for _, t := range types {
+		b.subscribers[t] = append(b.subscribers[t], ch)
+	}
+	b.mu.Unlock()
+
+	return ch
+}
+
+// Publish delivers event to every subscriber of its type, waiting while a
+// subscriber's buffer is full. It gives up when ctx is done, which only
+// happens when the publishing worker is shutting down.
+func (b *EventBus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	subscribers := b.subscribers[event.EventType()]
+	b.mu.RUnlock()
+
+	for _, ch := range subscribers {
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+			logger.Warn("Shutting down, dropping %s event", event.EventType())
+			return
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/tibia"
+)
+
+const expLockWorkerName = "exp-lock"
+
+// expLockHistoryDays is how many days of daily experience are kept in item
+// metadata. It must cover the longest supported period (monthly).
+const expLockHistoryDays = 31
+
+type ExpLockWorker struct {
+	session      *discordgo.Session
+	listRepo     *repositories.ListRepository
+	itemRepo     *repositories.ListItemRepository
+	tibiaClient  *tibia.Client
+	pollInterval time.Duration
+	location     *time.Location
+}
+
+func NewExpLockWorker(session *discordgo.Session, tibiaClient *tibia.Client) *ExpLockWorker {
+	return &ExpLockWorker{
+		session:      session,
+		listRepo:     repositories.NewListRepository(),
+		itemRepo:     repositories.NewListItemRepository(),
+		tibiaClient:  tibiaClient,
+		pollInterval: 5 * time.Minute,
+		location:     time.FixedZone("BRT", -3*60*60),
+	}
+}
+
+func (w *ExpLockWorker) Name() string {
+	return expLockWorkerName
+}
+
+func (w *ExpLockWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	w.checkExpLocks(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.checkExpLocks(ctx)
+		}
+	}
+}
+
+func (w *ExpLockWorker) checkExpLocks(ctx context.Context) {
+	lists, err := w.listRepo.FindByType("exp-lock")
+	if err != nil {
+		logger.Worker(expLockWorkerName, "Error fetching lists: %v", err)
+		return
+	}
+
+	if len(lists) == 0 {
+		return
+	}
+
+	powergamers, err := w.tibiaClient.GetPowergamersContext(ctx, "today", "", true)
+	if err != nil {
+		logger.Worker(expLockWorkerName, "Error fetching powergamers: %v", err)
+		return
+	}
+
+	todayExp := make(map[string]int, len(powergamers))
+	for _, pg := range powergamers {
+		todayExp[strings.TrimSpace(strings.ToLower(pg.Name))] = pg.Today
+	}
+
+	logger.Worker(expLockWorkerName, "Checking %d lists", len(lists))
+
+	today := time.Now().In(w.location)
+	for _, list := range lists {
+		items, err := w.itemRepo.FindByListID(list.ID)
+		if err != nil {
+			logger.Worker(expLockWorkerName, "Error fetching items for list %d: %v", list.ID, err)
+			continue
+		}
+
+		for _, item := range items {
+			gained, seen := todayExp[strings.TrimSpace(strings.ToLower(item.Name))]
+			w.checkCharacter(&list, &item, gained, seen, today)
+		}
+	}
+}
+
+// checkCharacter records today's experience and alerts when the period total
+// crosses the limit. When the character is missing from the powergamers list
+// (seen false), today's stored value is kept, since a short or failed fetch
+// must not be mistaken for a day without experience.
+func (w *ExpLockWorker) checkCharacter(list *database.List, item *database.ListItem, gainedToday int, seen bool, today time.Time) {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(item.Metadata, &metadata); err != nil {
+		metadata = make(map[string]interface{})
+	}
+
+	maxExp, ok := metadata["max_exp"].(float64)
+	if !ok || maxExp <= 0 {
+		logger.Worker(expLockWorkerName, "Skipping %s: no max_exp configured", item.Name)
+		return
+	}
+
+	period, _ := metadata["period"].(string)
+	days := expLockPeriodDays(period)
+
+	dailyExp := make(map[string]interface{})
+	if existing, ok := metadata["daily_exp"].(map[string]interface{}); ok {
+		dailyExp = existing
+	}
+
+	if seen {
+		dailyExp[today.Format("2006-01-02")] = gainedToday
+	}
+
+	cutoff := today.AddDate(0, 0, -expLockHistoryDays).Format("2006-01-02")
+	for day := range dailyExp {
+		if day < cutoff {
+			delete(dailyExp, day)
+		}
+	}
+
+	total := sumExpLockWindow(dailyExp, today, days)
+	wasOverLimit, _ := metadata["over_limit"].(bool)
+	isOverLimit := float64(total) > maxExp
+
+	metadata["daily_exp"] = dailyExp
+	metadata["period_exp"] = total
+	metadata["over_limit"] = isOverLimit
+
+	if isOverLimit && !wasOverLimit {
+		logger.Worker(expLockWorkerName, "%s crossed exp-lock threshold: %d > %d (%s)", item.Name, total, int(maxExp), period)
+		w.sendNotification(list, item, total, int(maxExp), period)
+	}
+
+	w.updateMetadata(item, metadata)
+}
+
+// expLockPeriodDays returns the rolling window length in days for an
+// exp-lock period. Unknown periods fall back to weekly.
+func expLockPeriodDays(period string) int {
+	switch period {
+	case "bi-weekly":
+		return 14
+	case "monthly":
+		return 30
+	default:
+		return 7
+	}
+}
+
+// sumExpLockWindow sums the daily experience entries that fall inside the
+// last `days` days, today included.
+func sumExpLockWindow(dailyExp map[string]interface{}, today time.Time, days int) int {
+	total := 0
+	for offset := 0; offset < days; offset++ {
+		key := today.AddDate(0, 0, -offset).Format("2006-01-02")
+		if exp, ok := dailyExp[key].(float64); ok {
+			total += int(exp)
+		} else if exp, ok := dailyExp[key].(int); ok {
+			total += exp
+		}
+	}
+	return total
+}
+
+func (w *ExpLockWorker) updateMetadata(item *database.ListItem, metadata map[string]interface{}) {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		logger.Error("Error encoding metadata: %v", err)
+		return
+	}
+
+	item.Metadata = metadataJSON
+	if err := w.itemRepo.Update(item); err != nil {
+		logger.Error("Error updating item: %v", err)
+	}
+}
+
+func (w *ExpLockWorker) sendNotification(list *database.List, item *database.ListItem, total, maxExp int, period string) {
+	embed := &discordgo.MessageEmbed{
+		Title:       "⚠️ Exp-Lock Threshold Exceeded",
+		Description: fmt.Sprintf("**%s** gained more experience than allowed for the %s period!", item.Name, period),
+		Color:       0xE67E22,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Gained",
+				Value:  tibia.FormatTibiaNumber(total),
+				Inline: true,
+			},
+			{
+				Name:   "Limit",
+				Value:  tibia.FormatTibiaNumber(maxExp),
+				Inline: true,
+			},
+			{
+				Name:   "Period",
+				Value:  period,
+				Inline: true,
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Exp-Lock Alert",
+		},
+	}
+
+	content := ""
+	if list.NotifyEveryone {
+		content = "@everyone"
+	}
+
+	_, err := w.session.ChannelMessageSendComplex(list.ChannelID, &discordgo.MessageSend{
+		Content: content,
+		Embed:   embed,
+	})
+
+	if err != nil {
+		logger.Error("Error sending notification: %v", err)
+	}
+}
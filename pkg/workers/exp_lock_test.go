@@ -0,0 +1,54 @@
+package workers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSumExpLockWindow(t *testing.T) {
+	today := time.Date(2026, 10, 18, 15, 0, 0, 0, time.FixedZone("BRT", -3*60*60))
+
+	dailyExp := map[string]interface{}{
+		"2026-10-18": 1000,          // today, as stored before encoding
+		"2026-10-17": float64(2000), // as decoded from JSON
+		"2026-10-12": float64(4000), // first day of a weekly window
+		"2026-10-11": float64(8000), // just outside it
+		"2026-09-19": float64(16000),
+		"2026-10-16": "bad value",
+	}
+
+	tests := []struct {
+		name string
+		days int
+		want int
+	}{
+		{name: "today only", days: 1, want: 1000},
+		{name: "weekly", days: 7, want: 7000},
+		{name: "bi-weekly", days: 14, want: 15000},
+		{name: "monthly", days: 30, want: 31000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sumExpLockWindow(dailyExp, today, tt.days); got != tt.want {
+				t.Errorf("sumExpLockWindow(%d days) = %d, want %d", tt.days, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpLockPeriodDays(t *testing.T) {
+	tests := map[string]int{
+		"weekly":    7,
+		"bi-weekly": 14,
+		"monthly":   30,
+		"":          7,
+		"yearly":    7,
+	}
+
+	for period, want := range tests {
+		if got := expLockPeriodDays(period); got != want {
+			t.Errorf("expLockPeriodDays(%q) = %d, want %d", period, got, want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/tibia"
+)
+
+const guildChangeWorkerName = "guild-change"
+
+// maxEmbedFieldLength is the maximum length Discord accepts for an embed field value.
+const maxEmbedFieldLength = 1024
+
+// GuildChangeWorker reports guild joins, leaves, switches and rank changes.
+// Character items are diffed by the character refresh stage and arrive as
+// GuildChangedEvents; guild items carry a "watch_guild_id" metadata key and
+// are polled here against the guild's member list.
+type GuildChangeWorker struct {
+	session      *discordgo.Session
+	listRepo     *repositories.ListRepository
+	itemRepo     *repositories.ListItemRepository
+	tibiaClient  *tibia.Client
+	events       <-chan Event
+	pollInterval time.Duration
+}
+
+func NewGuildChangeWorker(session *discordgo.Session, tibiaClient *tibia.Client, bus *EventBus) *GuildChangeWorker {
+	return &GuildChangeWorker{
+		session:      session,
+		listRepo:     repositories.NewListRepository(),
+		itemRepo:     repositories.NewListItemRepository(),
+		tibiaClient:  tibiaClient,
+		events:       bus.Subscribe(EventGuildChanged),
+		pollInterval: 1 * time.Minute,
+	}
+}
+
+func (w *GuildChangeWorker) Name() string {
+	return guildChangeWorkerName
+}
+
+func (w *GuildChangeWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	w.checkGuildRosters(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.checkGuildRosters(ctx)
+		case event := <-w.events:
+			if changed, ok := event.(GuildChangedEvent); ok {
+				w.notifyCharacterChange(changed)
+			}
+		}
+	}
+}
+
+// isGuildWatch reports whether an item watches a whole guild rather than a
+// single character.
+func isGuildWatch(item *database.ListItem) bool {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(item.Metadata, &metadata); err != nil {
+		return false
+	}
+	_, ok := metadata["watch_guild_id"]
+	return ok
+}
+
+func (w *GuildChangeWorker) checkGuildRosters(ctx context.Context) {
+	lists, err := w.listRepo.FindByType("guild-change")
+	if err != nil {
+		logger.Worker(guildChangeWorkerName, "Error fetching lists: %v", err)
+		return
+	}
+
+	for _, list := range lists {
+		items, err := w.itemRepo.FindByListID(list.ID)
+		if err != nil {
+			logger.Worker(guildChangeWorkerName, "Error fetching items for list %d: %v", list.ID, err)
+			continue
+		}
+
+		for _, item := range items {
+			var metadata map[string]interface{}
+			if err := json.Unmarshal(item.Metadata, &metadata); err != nil {
+				continue
+			}
+
+			if guildID, ok := metadata["watch_guild_id"].(float64); ok {
+				w.checkGuild(ctx, &list, &item, metadata, int(guildID))
+			}
+		}
+	}
+}
+
+func diffGuild(list *database.List, item *database.ListItem, character *tibia.Character, metadata map[string]interface{}) ([]Event, bool) {
+	lastGuild, hasGuild := metadata["guild"].(string)
+	lastRank, _ := metadata["guild_rank"].(string)
+
+	if !hasGuild {
+		metadata["guild"] = character.Guild
+		metadata["guild_rank"] = character.GuildRank
+		logger.Worker(guildChangeWorkerName, "Initial guild for %s: %q (%s)", item.Name, character.Guild, character.GuildRank)
+		return nil, true
+	}
+
+	if lastGuild == character.Guild && lastRank == character.GuildRank {
+		return nil, false
+	}
+
+	logger.Worker(guildChangeWorkerName, "Guild changed for %s: %q (%s) -> %q (%s)", item.Name, lastGuild, lastRank, character.Guild, character.GuildRank)
+	metadata["guild"] = character.Guild
+	metadata["guild_rank"] = character.GuildRank
+	return []Event{GuildChangedEvent{
+		ItemEvent: ItemEvent{List: *list, Item: *item},
+		OldGuild:  lastGuild,
+		OldRank:   lastRank,
+		NewGuild:  character.Guild,
+		NewRank:   character.GuildRank,
+	}}, true
+}
+
+func (w *GuildChangeWorker) notifyCharacterChange(event GuildChangedEvent) {
+	name := event.Item.Name
+
+	var title, description string
+	switch {
+	case event.OldGuild == "":
+		title = "📥 Joined Guild"
+		description = fmt.Sprintf("**%s** joined **%s** as %s", name, event.NewGuild, event.NewRank)
+	case event.NewGuild == "":
+		title = "📤 Left Guild"
+		description = fmt.Sprintf("**%s** left **%s**", name, event.OldGuild)
+	case event.OldGuild != event.NewGuild:
+		title = "🔀 Switched Guild"
+		description = fmt.Sprintf("**%s** moved from **%s** to **%s** as %s", name, event.OldGuild, event.NewGuild, event.NewRank)
+	default:
+		title = "🎖️ Rank Changed"
+		description = fmt.Sprintf("**%s** is now %s in **%s** (was %s)", name, event.NewRank, event.NewGuild, event.OldRank)
+	}
+
+	w.sendNotification(&event.List, &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       0x9B59B6,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Guild Alert",
+		},
+	})
+}
+
+func (w *GuildChangeWorker) checkGuild(ctx context.Context, list *database.List, item *database.ListItem, metadata map[string]interface{}, guildID int) {
+	guild, err := w.tibiaClient.GetGuildMembersContext(ctx, guildID)
+	if err != nil {
+		logger.Worker(guildChangeWorkerName, "Error fetching guild %d: %v", guildID, err)
+		return
+	}
+
+	current := make(map[string]string, len(guild.Members))
+	for _, member := range guild.Members {
+		current[member.Name] = member.Rank
+	}
+
+	storedMembers, hasMembers := metadata["members"].(map[string]interface{})
+	if !hasMembers {
+		metadata["members"] = current
+		w.updateMetadata(item, metadata)
+		logger.Worker(guildChangeWorkerName, "Initial member list for guild %d: %d members", guildID, len(current))
+		return
+	}
+
+	// A guild always has a leader, so an empty roster is a bad response
+	// rather than everyone leaving. Keep the stored roster until it recovers.
+	if len(current) == 0 && len(storedMembers) > 0 {
+		logger.Worker(guildChangeWorkerName, "Guild %d returned no members, keeping the stored %d", guildID, len(storedMembers))
+		return
+	}
+
+	var joined, left, promoted []string
+	for name, rank := range current {
+		oldRank, wasMember := storedMembers[name].(string)
+		if !wasMember {
+			joined = append(joined, fmt.Sprintf("%s (%s)", name, rank))
+		} else if oldRank != rank {
+			promoted = append(promoted, fmt.Sprintf("%s: %s → %s", name, oldRank, rank))
+		}
+	}
+	for name := range storedMembers {
+		if _, stillMember := current[name]; !stillMember {
+			left = append(left, name)
+		}
+	}
+
+	if len(joined) == 0 && len(left) == 0 && len(promoted) == 0 {
+		return
+	}
+
+	sort.Strings(joined)
+	sort.Strings(left)
+	sort.Strings(promoted)
+
+	fields := make([]*discordgo.MessageEmbedField, 0, 3)
+	if len(joined) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("📥 Joined (%d)", len(joined)),
+			Value: truncateFieldLines(joined),
+		})
+	}
+	if len(left) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("📤 Left (%d)", len(left)),
+			Value: truncateFieldLines(left),
+		})
+	}
+	if len(promoted) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("🎖️ Rank Changes (%d)", len(promoted)),
+			Value: truncateFieldLines(promoted),
+		})
+	}
+
+	logger.Worker(guildChangeWorkerName, "Guild %d changed: %d joined, %d left, %d rank changes", guildID, len(joined), len(left), len(promoted))
+	w.sendNotification(list, &discordgo.MessageEmbed{
+		Title:       "🏰 Guild Roster Changed",
+		Description: fmt.Sprintf("Membership of **%s** changed (%d members now)", item.Name, len(current)),
+		Color:       0x9B59B6,
+		Fields:      fields,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Guild Alert",
+		},
+	})
+
+	metadata["members"] = current
+	w.updateMetadata(item, metadata)
+}
+
+// truncateFieldLines joins lines for an embed field, cutting the list short
+// when it would exceed Discord's field length limit.
+func truncateFieldLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		suffix := fmt.Sprintf("\n…and %d more", len(lines)-i)
+		if b.Len()+len(line)+1+len(suffix) > maxEmbedFieldLength {
+			b.WriteString(strings.TrimPrefix(suffix, "\n"))
+			break
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (w *GuildChangeWorker) updateMetadata(item *database.ListItem, metadata map[string]interface{}) {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		logger.Error("Error encoding metadata: %v", err)
+		return
+	}
+
+	item.Metadata = metadataJSON
+	if err := w.itemRepo.Update(item); err != nil {
+		logger.Error("Error updating item: %v", err)
+	}
+}
+
+func (w *GuildChangeWorker) sendNotification(list *database.List, embed *discordgo.MessageEmbed) {
+	content := ""
+	if list.NotifyEveryone {
+		content = "@everyone"
+	}
+
+	_, err := w.session.ChannelMessageSendComplex(list.ChannelID, &discordgo.MessageSend{
+		Content: content,
+		Embed:   embed,
+	})
+
+	if err != nil {
+		logger.Error("Error sending notification: %v", err)
+	}
+}
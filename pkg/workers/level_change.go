@@ -0,0 +1,210 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+)
+
+const levelChangeWorkerName = "level-change"
+
+// LevelChangeWorker reports level ups and level losses for list items. It
+// reads the levels the online tracker already stores on players, so it does
+// not make any Tibia API requests of its own.
+type LevelChangeWorker struct {
+	session      *discordgo.Session
+	listRepo     *repositories.ListRepository
+	itemRepo     *repositories.ListItemRepository
+	playerRepo   *repositories.PlayerRepository
+	pollInterval time.Duration
+}
+
+func NewLevelChangeWorker(session *discordgo.Session) *LevelChangeWorker {
+	return &LevelChangeWorker{
+		session:      session,
+		listRepo:     repositories.NewListRepository(),
+		itemRepo:     repositories.NewListItemRepository(),
+		playerRepo:   repositories.NewPlayerRepository(),
+		pollInterval: 1 * time.Minute,
+	}
+}
+
+func (w *LevelChangeWorker) Name() string {
+	return levelChangeWorkerName
+}
+
+func (w *LevelChangeWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	w.checkLevels()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.checkLevels()
+		}
+	}
+}
+
+func (w *LevelChangeWorker) checkLevels() {
+	lists, err := w.listRepo.FindByType("level-change")
+	if err != nil {
+		logger.Worker(levelChangeWorkerName, "Error fetching lists: %v", err)
+		return
+	}
+
+	logger.Worker(levelChangeWorkerName, "Checking %d lists", len(lists))
+
+	for _, list := range lists {
+		items, err := w.itemRepo.FindByListID(list.ID)
+		if err != nil {
+			logger.Worker(levelChangeWorkerName, "Error fetching items for list %d: %v", list.ID, err)
+			continue
+		}
+
+		if len(items) == 0 {
+			continue
+		}
+
+		names := make([]string, len(items))
+		for idx, item := range items {
+			names[idx] = item.Name
+		}
+
+		players, err := w.playerRepo.FindByNames(names)
+		if err != nil {
+			logger.Worker(levelChangeWorkerName, "Error fetching players for list %d: %v", list.ID, err)
+			continue
+		}
+
+		levels := make(map[string]int, len(players))
+		for _, player := range players {
+			levels[strings.ToLower(player.Name)] = player.Level
+		}
+
+		for _, item := range items {
+			level, tracked := levels[strings.ToLower(strings.TrimSpace(item.Name))]
+			if !tracked || level <= 0 {
+				continue
+			}
+			w.checkCharacter(&list, &item, level)
+		}
+	}
+}
+
+func (w *LevelChangeWorker) checkCharacter(list *database.List, item *database.ListItem, currentLevel int) {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(item.Metadata, &metadata); err != nil {
+		metadata = make(map[string]interface{})
+	}
+
+	lastLevelValue, hasLevel := metadata["level"].(float64)
+
+	if !hasLevel {
+		metadata["level"] = currentLevel
+		w.updateMetadata(item, metadata)
+		logger.Worker(levelChangeWorkerName, "Initial level for %s: %d", item.Name, currentLevel)
+		return
+	}
+
+	lastLevel := int(lastLevelValue)
+	delta := currentLevel - lastLevel
+	if delta == 0 {
+		return
+	}
+
+	minDelta := list.MinLevelDelta
+	if minDelta < 1 {
+		minDelta = 1
+	}
+
+	// Changes below the list's minimum are left to accumulate against the
+	// stored level instead of being dropped.
+	if delta < minDelta && -delta < minDelta {
+		return
+	}
+
+	logger.Worker(levelChangeWorkerName, "Level changed for %s: %d -> %d", item.Name, lastLevel, currentLevel)
+	w.sendNotification(list, item, lastLevel, currentLevel)
+	metadata["level"] = currentLevel
+	w.updateMetadata(item, metadata)
+}
+
+func (w *LevelChangeWorker) updateMetadata(item *database.ListItem, metadata map[string]interface{}) {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		logger.Error("Error encoding metadata: %v", err)
+		return
+	}
+
+	item.Metadata = metadataJSON
+	if err := w.itemRepo.Update(item); err != nil {
+		logger.Error("Error updating item: %v", err)
+	}
+}
+
+func (w *LevelChangeWorker) sendNotification(list *database.List, item *database.ListItem, oldLevel, newLevel int) {
+	var title, description string
+	var color int
+
+	if newLevel > oldLevel {
+		title = "⬆️ Level Up"
+		description = fmt.Sprintf("**%s** advanced from level %d to level %d!", item.Name, oldLevel, newLevel)
+		color = 0x00FF00
+	} else {
+		title = "💀 Level Lost"
+		description = fmt.Sprintf("**%s** dropped from level %d to level %d (likely a death).", item.Name, oldLevel, newLevel)
+		color = 0xFF0000
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Previous Level",
+				Value:  fmt.Sprintf("%d", oldLevel),
+				Inline: true,
+			},
+			{
+				Name:   "New Level",
+				Value:  fmt.Sprintf("%d", newLevel),
+				Inline: true,
+			},
+			{
+				Name:   "Change",
+				Value:  fmt.Sprintf("%+d", newLevel-oldLevel),
+				Inline: true,
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Level Alert",
+		},
+	}
+
+	content := ""
+	if list.NotifyEveryone {
+		content = "@everyone"
+	}
+
+	_, err := w.session.ChannelMessageSendComplex(list.ChannelID, &discordgo.MessageSend{
+		Content: content,
+		Embed:   embed,
+	})
+
+	if err != nil {
+		logger.Error("Error sending notification: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/tibia"
 )
 
 type Worker interface {
@@ -19,14 +20,35 @@ type Manager struct {
 	cancel  context.CancelFunc
 }
 
-func NewManager(session *discordgo.Session, tibiaAPIURL string) *Manager {
+// NewManager builds every worker. One online tracker runs per entry in
+// trackedWorlds; an empty list tracks the API's default world.
+func NewManager(session *discordgo.Session, tibiaClient *tibia.Client, trackedWorlds []string) *Manager {
+	bus := NewEventBus()
+
+	workers := []Worker{
+		NewCharacterRefreshWorker(tibiaClient, bus),
+		NewPremiumWorker(session, bus),
+		NewResidenceWorker(session, bus),
+		NewPowergamesStatsWorker(session, tibiaClient),
+		NewPresenceWorker(session, bus),
+		NewExpLockWorker(session, tibiaClient),
+		NewLevelChangeWorker(session),
+		NewDeathWorker(session, bus),
+		NewGuildChangeWorker(session, tibiaClient, bus),
+		NewRenameWorker(session, bus),
+		NewFormerNameCheckWorker(tibiaClient, bus),
+		NewScannerWorker(session),
+	}
+
+	if len(trackedWorlds) == 0 {
+		trackedWorlds = []string{""}
+	}
+	for _, world := range trackedWorlds {
+		workers = append(workers, NewOnlineTrackerWorker(session, tibiaClient, bus, world))
+	}
+
 	return &Manager{
-		workers: []Worker{
-			NewPremiumWorker(session, tibiaAPIURL),
-			NewResidenceWorker(session, tibiaAPIURL),
-			NewPowergamesStatsWorker(session, tibiaAPIURL),
-			NewOnlineTrackerWorker(session, tibiaAPIURL),
-		},
+		workers: workers,
 	}
 }
 
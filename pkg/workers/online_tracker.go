@@ -2,9 +2,11 @@ package workers
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/database"
 	"github.com/ethaan/discord-api/pkg/logger"
 	"github.com/ethaan/discord-api/pkg/repositories"
 	"github.com/ethaan/discord-api/pkg/tibia"
@@ -12,99 +14,297 @@ import (
 
 const onlineTrackerWorkerName = "online-tracker"
 
+// OnlineTrackerWorker records login/logout sessions for one world. An empty
+// world follows whatever world the API deployment defaults to.
+//
+// Sessions are left open on shutdown. On startup the tracker picks up the
+// open sessions for its world, so a short restart does not split them into
+// fake logout/login pairs.
 type OnlineTrackerWorker struct {
+	name              string
+	world             string
 	session           *discordgo.Session
 	playerRepo        *repositories.PlayerRepository
 	sessionRepo       *repositories.OnlineSessionRepository
+	stateRepo         *repositories.TrackerStateRepository
+	gapRepo           *repositories.PollingGapRepository
 	tibiaClient       *tibia.Client
 	pollInterval      time.Duration
 	lastOnlinePlayers map[string]uint
+	bus               *EventBus
+	// hasBaseline is false until the first successful poll; transitions seen
+	// on that poll only reflect the tracker starting, so they are not published.
+	hasBaseline bool
+	// reconciled is set once open sessions have been loaded from the database.
+	reconciled bool
+	// lastPollAt is the time of the last successful poll, restored from the
+	// database on startup.
+	lastPollAt time.Time
+	// maxPollGap is the longest gap between polls after which transitions are
+	// still recorded as exact.
+	maxPollGap time.Duration
+	// restartGrace is the longest gap open sessions survive. Past it, players
+	// still online are assumed to have relogged at some point in between.
+	restartGrace time.Duration
 }
 
-func NewOnlineTrackerWorker(session *discordgo.Session, tibiaAPIURL string) *OnlineTrackerWorker {
+func NewOnlineTrackerWorker(session *discordgo.Session, tibiaClient *tibia.Client, bus *EventBus, world string) *OnlineTrackerWorker {
+	name := onlineTrackerWorkerName
+	if world != "" {
+		name = fmt.Sprintf("%s:%s", onlineTrackerWorkerName, world)
+	}
+
+	pollInterval := 10 * time.Second
+
 	return &OnlineTrackerWorker{
+		name:              name,
+		world:             world,
 		session:           session,
 		playerRepo:        repositories.NewPlayerRepository(),
 		sessionRepo:       repositories.NewOnlineSessionRepository(),
-		tibiaClient:       tibia.NewClient(tibiaAPIURL),
-		pollInterval:      10 * time.Second,
+		stateRepo:         repositories.NewTrackerStateRepository(),
+		gapRepo:           repositories.NewPollingGapRepository(),
+		tibiaClient:       tibiaClient,
+		pollInterval:      pollInterval,
 		lastOnlinePlayers: make(map[string]uint),
+		bus:               bus,
+		maxPollGap:        3 * pollInterval,
+		restartGrace:      5 * time.Minute,
 	}
 }
 
 func (w *OnlineTrackerWorker) Name() string {
-	return onlineTrackerWorkerName
+	return w.name
 }
 
 func (w *OnlineTrackerWorker) Run(ctx context.Context) {
 	ticker := time.NewTicker(w.pollInterval)
 	defer ticker.Stop()
 
-	w.trackOnlinePlayers()
+	w.trackOnlinePlayers(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
-			w.closeAllActiveSessions()
 			return
 		case <-ticker.C:
-			w.trackOnlinePlayers()
+			w.trackOnlinePlayers(ctx)
+		}
+	}
+}
+
+// reconcile seeds the tracker with the sessions left open by a previous run.
+func (w *OnlineTrackerWorker) reconcile() error {
+	state, err := w.stateRepo.Get(w.world)
+	if err != nil {
+		return fmt.Errorf("failed to load tracker state: %w", err)
+	}
+
+	if w.world != "" {
+		if err := w.closeLegacySessions(); err != nil {
+			return err
 		}
 	}
+
+	openSessions, err := w.sessionRepo.FindOpenSessions(w.world)
+	if err != nil {
+		return fmt.Errorf("failed to load open sessions: %w", err)
+	}
+
+	if state != nil {
+		w.lastPollAt = state.LastPollAt
+	}
+	for _, open := range openSessions {
+		w.lastOnlinePlayers[open.PlayerName] = open.PlayerID
+	}
+
+	w.reconciled = true
+	logger.Worker(w.name, "Resumed %d open sessions", len(openSessions))
+	return nil
 }
 
-func (w *OnlineTrackerWorker) trackOnlinePlayers() {
-	response, err := w.tibiaClient.GetWhosOnline()
+// closeLegacySessions closes the sessions a default-world tracker left open
+// before sessions recorded their world. No tracker can resume them once
+// worlds are configured, so they would otherwise stay open forever.
+func (w *OnlineTrackerWorker) closeLegacySessions() error {
+	legacy, err := w.stateRepo.Get("")
 	if err != nil {
-		logger.Worker(onlineTrackerWorkerName, "Error fetching whos online: %v", err)
+		return fmt.Errorf("failed to load legacy tracker state: %w", err)
+	}
+
+	var lastSeenAt time.Time
+	if legacy != nil {
+		lastSeenAt = legacy.LastPollAt
+	}
+
+	closed, err := w.sessionRepo.CloseLegacySessions(lastSeenAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to close legacy sessions: %w", err)
+	}
+	if closed > 0 {
+		logger.Worker(w.name, "Closed %d sessions left open without a world", closed)
+	}
+	return nil
+}
+
+func (w *OnlineTrackerWorker) trackOnlinePlayers(ctx context.Context) {
+	if !w.reconciled {
+		if err := w.reconcile(); err != nil {
+			logger.Worker(w.name, "Error reconciling sessions: %v", err)
+			return
+		}
+	}
+
+	response, err := w.tibiaClient.GetWhosOnlineWorldContext(ctx, w.world)
+	if err != nil {
+		logger.Worker(w.name, "Error fetching whos online: %v", err)
 		return
 	}
 
 	now := time.Now()
-	currentOnline := make(map[string]uint)
 
+	// Transitions noticed after a gap in polling happened at some unknown
+	// point since the last poll: logouts are stamped at the start of the gap
+	// and logins at its end, with the other boundary kept as the bound.
+	gap := now.Sub(w.lastPollAt)
+	uncertain := w.lastPollAt.IsZero() || gap > w.maxPollGap
+	resumeSessions := !w.lastPollAt.IsZero() && gap <= w.restartGrace
+
+	lastSeenAt := now
+	var gapStart, gapEnd *time.Time
+	if uncertain && !w.lastPollAt.IsZero() {
+		lastSeenAt = w.lastPollAt
+		gapStart, gapEnd = &lastSeenAt, &now
+	}
+
+	rows := make([]database.Player, 0, len(response.Players))
+	seen := make(map[string]bool, len(response.Players))
 	for _, player := range response.Players {
-		dbPlayer, err := w.playerRepo.FindOrCreate(player.Name, player.Level, player.Vocation, player.Country)
-		if err != nil {
-			logger.Worker(onlineTrackerWorkerName, "Error creating/updating player %s: %v", player.Name, err)
+		if seen[player.Name] {
 			continue
 		}
+		seen[player.Name] = true
+		rows = append(rows, database.Player{
+			Name:     player.Name,
+			World:    w.world,
+			Level:    player.Level,
+			Vocation: player.Vocation,
+			Country:  player.Country,
+		})
+	}
+
+	if err := w.playerRepo.UpsertBatch(rows); err != nil {
+		logger.Worker(w.name, "Error upserting %d players: %v", len(rows), err)
+		return
+	}
+
+	currentOnline := make(map[string]uint, len(rows))
+	playerIDs := make([]uint, 0, len(rows)+len(w.lastOnlinePlayers))
+	for _, row := range rows {
+		currentOnline[row.Name] = row.ID
+		playerIDs = append(playerIDs, row.ID)
+	}
+	for name, playerID := range w.lastOnlinePlayers {
+		if _, isOnline := currentOnline[name]; !isOnline {
+			playerIDs = append(playerIDs, playerID)
+		}
+	}
+
+	active, err := w.sessionRepo.FindActiveSessions(w.world, playerIDs)
+	if err != nil {
+		logger.Worker(w.name, "Error fetching active sessions: %v", err)
+		return
+	}
+
+	var opened []database.OnlineSession
+	var closedIDs []uint
+	var events []PresenceEvent
 
-		currentOnline[player.Name] = dbPlayer.ID
+	openSession := func(playerID uint) {
+		opened = append(opened, database.OnlineSession{
+			PlayerID:        playerID,
+			World:           w.world,
+			LoginAt:         now,
+			LoginUncertain:  uncertain,
+			LoginAtEarliest: gapStart,
+		})
+	}
+
+	for _, row := range rows {
+		activeSession, hasActive := active[row.ID]
 
-		if _, wasOnline := w.lastOnlinePlayers[player.Name]; !wasOnline {
-			activeSession, err := w.sessionRepo.FindActiveSession(dbPlayer.ID)
-			if err != nil || activeSession == nil {
-				if err := w.sessionRepo.CreateSession(dbPlayer.ID, now); err != nil {
-					logger.Worker(onlineTrackerWorkerName, "Error creating session for %s: %v", player.Name, err)
-				}
+		if _, wasOnline := w.lastOnlinePlayers[row.Name]; wasOnline {
+			// Past the restart grace the player may have relogged during the
+			// gap, so the session is split. uncertain is always set here.
+			if !resumeSessions && hasActive {
+				closedIDs = append(closedIDs, activeSession.ID)
+				openSession(row.ID)
 			}
+			continue
+		}
+
+		if !hasActive {
+			openSession(row.ID)
 		}
+
+		events = append(events, PresenceEvent{
+			Type:     EventLogin,
+			Name:     row.Name,
+			World:    w.world,
+			PlayerID: row.ID,
+			Level:    row.Level,
+			Vocation: row.Vocation,
+			At:       now,
+		})
 	}
 
 	for name, playerID := range w.lastOnlinePlayers {
-		if _, isOnline := currentOnline[name]; !isOnline {
-			activeSession, err := w.sessionRepo.FindActiveSession(playerID)
-			if err == nil && activeSession != nil {
-				if err := w.sessionRepo.CloseSession(activeSession.ID, now); err != nil {
-					logger.Worker(onlineTrackerWorkerName, "Error closing session for %s: %v", name, err)
-				}
-			}
+		if _, isOnline := currentOnline[name]; isOnline {
+			continue
 		}
+
+		event := PresenceEvent{
+			Type:     EventLogout,
+			Name:     name,
+			World:    w.world,
+			PlayerID: playerID,
+			At:       now,
+		}
+
+		if activeSession, ok := active[playerID]; ok {
+			closedIDs = append(closedIDs, activeSession.ID)
+			loginAt := activeSession.LoginAt
+			event.LoginAt = &loginAt
+		}
+
+		events = append(events, event)
 	}
 
-	w.lastOnlinePlayers = currentOnline
-	logger.Worker(onlineTrackerWorkerName, "Tracked %d online players", len(currentOnline))
-}
+	if err := w.sessionRepo.ApplyTransitions(opened, closedIDs, lastSeenAt, uncertain, gapEnd); err != nil {
+		logger.Worker(w.name, "Error saving %d logins and %d logouts: %v", len(opened), len(closedIDs), err)
+		return
+	}
 
-func (w *OnlineTrackerWorker) closeAllActiveSessions() {
-	now := time.Now()
-	for name, playerID := range w.lastOnlinePlayers {
-		activeSession, err := w.sessionRepo.FindActiveSession(playerID)
-		if err == nil && activeSession != nil {
-			if err := w.sessionRepo.CloseSession(activeSession.ID, now); err != nil {
-				logger.Worker(onlineTrackerWorkerName, "Error closing session for %s on shutdown: %v", name, err)
-			}
+	if gapStart != nil {
+		logger.Worker(w.name, "Polling gap of %s, marking transitions as uncertain", gap.Round(time.Second))
+		if err := w.gapRepo.Create(w.world, *gapStart, now); err != nil {
+			logger.Worker(w.name, "Error recording polling gap: %v", err)
 		}
 	}
+
+	if w.hasBaseline {
+		for _, event := range events {
+			w.bus.Publish(ctx, event)
+		}
+	}
+
+	w.lastOnlinePlayers = currentOnline
+	w.lastPollAt = now
+	w.hasBaseline = true
+
+	if err := w.stateRepo.Save(w.world, now); err != nil {
+		logger.Worker(w.name, "Error saving tracker state: %v", err)
+	}
+
+	logger.Worker(w.name, "Tracked %d online players in %s", len(currentOnline), time.Since(now).Round(time.Millisecond))
 }
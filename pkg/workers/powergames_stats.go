@@ -22,12 +22,12 @@ type PowergamesStatsWorker struct {
 	pollInterval time.Duration
 }
 
-func NewPowergamesStatsWorker(session *discordgo.Session, tibiaAPIURL string) *PowergamesStatsWorker {
+func NewPowergamesStatsWorker(session *discordgo.Session, tibiaClient *tibia.Client) *PowergamesStatsWorker {
 	return &PowergamesStatsWorker{
 		session:      session,
 		listRepo:     repositories.NewListRepository(),
 		itemRepo:     repositories.NewListItemRepository(),
-		tibiaClient:  tibia.NewClient(tibiaAPIURL),
+		tibiaClient:  tibiaClient,
 		pollInterval: 1 * time.Minute,
 	}
 }
@@ -40,19 +40,19 @@ func (w *PowergamesStatsWorker) Run(ctx context.Context) {
 	ticker := time.NewTicker(w.pollInterval)
 	defer ticker.Stop()
 
-	w.updateAllStats()
+	w.updateAllStats(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			w.updateAllStats()
+			w.updateAllStats(ctx)
 		}
 	}
 }
 
-func (w *PowergamesStatsWorker) updateAllStats() {
+func (w *PowergamesStatsWorker) updateAllStats(ctx context.Context) {
 	lists, err := w.listRepo.FindByType("powergames-stats")
 	if err != nil {
 		logger.Worker("powergames-stats", "Error fetching lists: %v", err)
@@ -62,15 +62,16 @@ func (w *PowergamesStatsWorker) updateAllStats() {
 	logger.Worker("powergames-stats", "Updating %d channels", len(lists))
 
 	for _, list := range lists {
-		if err := w.updateChannelStats(&list); err != nil {
+		if err := w.updateChannelStats(ctx, &list); err != nil {
 			logger.Worker("powergames-stats", "Error updating channel %s: %v", list.ChannelID, err)
 		}
 
+		// The Tibia client is rate limited, but Discord message edits are not
 		time.Sleep(500 * time.Millisecond)
 	}
 }
 
-func (w *PowergamesStatsWorker) updateChannelStats(list *database.List) error {
+func (w *PowergamesStatsWorker) updateChannelStats(ctx context.Context, list *database.List) error {
 	items, err := w.itemRepo.FindByListID(list.ID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch list items: %w", err)
@@ -81,7 +82,7 @@ func (w *PowergamesStatsWorker) updateChannelStats(list *database.List) error {
 		return nil
 	}
 
-	powergamers, err := w.tibiaClient.GetPowergamers("today", "", false)
+	powergamers, err := w.tibiaClient.GetPowergamersContext(ctx, "today", "", false)
 	if err != nil {
 		return fmt.Errorf("failed to fetch powergamers: %w", err)
 	}
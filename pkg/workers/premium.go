@@ -2,32 +2,24 @@ package workers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/ethaan/discord-api/pkg/database"
 	"github.com/ethaan/discord-api/pkg/logger"
-	"github.com/ethaan/discord-api/pkg/repositories"
 	"github.com/ethaan/discord-api/pkg/tibia"
 )
 
 type PremiumWorker struct {
-	session      *discordgo.Session
-	listRepo     *repositories.ListRepository
-	itemRepo     *repositories.ListItemRepository
-	tibiaClient  *tibia.Client
-	pollInterval time.Duration
+	session *discordgo.Session
+	events  <-chan Event
 }
 
-func NewPremiumWorker(session *discordgo.Session, tibiaAPIURL string) *PremiumWorker {
+func NewPremiumWorker(session *discordgo.Session, bus *EventBus) *PremiumWorker {
 	return &PremiumWorker{
-		session:      session,
-		listRepo:     repositories.NewListRepository(),
-		itemRepo:     repositories.NewListItemRepository(),
-		tibiaClient:  tibia.NewClient(tibiaAPIURL),
-		pollInterval: 30 * time.Second,
+		session: session,
+		events:  bus.Subscribe(EventPremiumChanged),
 	}
 }
 
@@ -36,86 +28,40 @@ func (w *PremiumWorker) Name() string {
 }
 
 func (w *PremiumWorker) Run(ctx context.Context) {
-	ticker := time.NewTicker(w.pollInterval)
-	defer ticker.Stop()
-
-	w.checkPremiumStatus()
-
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			w.checkPremiumStatus()
-		}
-	}
-}
-
-func (w *PremiumWorker) checkPremiumStatus() {
-	lists, err := w.listRepo.FindByType("premium-alerts")
-	if err != nil {
-		logger.Worker("premium-alerts", "Error fetching lists: %v", err)
-		return
-	}
-
-	logger.Worker("premium-alerts", "Checking %d lists", len(lists))
-
-	for _, list := range lists {
-		items, err := w.itemRepo.FindByListID(list.ID)
-		if err != nil {
-			logger.Worker("premium-alerts", "Error fetching items for list %d: %v", list.ID, err)
-			continue
-		}
-
-		for _, item := range items {
-			w.checkCharacter(&list, &item)
-			time.Sleep(1 * time.Second)
+		case event := <-w.events:
+			if changed, ok := event.(PremiumChangedEvent); ok {
+				w.sendNotification(&changed.List, &changed.Item, changed.New)
+			}
 		}
 	}
 }
 
-func (w *PremiumWorker) checkCharacter(list *database.List, item *database.ListItem) {
-	character, err := w.tibiaClient.GetCharacter(item.Name)
-	if err != nil {
-		logger.Worker("premium-alerts", "Error fetching character %s: %v", item.Name, err)
-		return
-	}
-
+func diffPremium(list *database.List, item *database.ListItem, character *tibia.Character, metadata map[string]interface{}) ([]Event, bool) {
 	isPremium := character.IsPremium
 
-	var metadata map[string]interface{}
-	if err := json.Unmarshal(item.Metadata, &metadata); err != nil {
-		metadata = make(map[string]interface{})
-	}
-
 	lastStatus, hasStatus := metadata["premium_status"].(bool)
 
 	if !hasStatus {
 		metadata["premium_status"] = isPremium
-		w.updateMetadata(item, metadata)
 		logger.Worker("premium-alerts", "Initial status for %s: premium=%v", item.Name, isPremium)
-		return
+		return nil, true
 	}
 
-	if lastStatus != isPremium {
-		logger.Worker("premium-alerts", "Status changed for %s: %v -> %v", item.Name, lastStatus, isPremium)
-		w.sendNotification(list, item, isPremium)
-		metadata["premium_status"] = isPremium
-		w.updateMetadata(item, metadata)
+	if lastStatus == isPremium {
+		return nil, false
 	}
-}
 
-func (w *PremiumWorker) updateMetadata(item *database.ListItem, metadata map[string]interface{}) {
-	metadataJSON, err := json.Marshal(metadata)
-	if err != nil {
-		logger.Error("Error encoding metadata: %v", err)
-		return
-	}
-
-	item.Metadata = metadataJSON
-	if err := w.itemRepo.Update(item); err != nil {
-		logger.Error("Error updating item: %v", err)
-	}
+	logger.Worker("premium-alerts", "Status changed for %s: %v -> %v", item.Name, lastStatus, isPremium)
+	metadata["premium_status"] = isPremium
+	return []Event{PremiumChangedEvent{
+		ItemEvent: ItemEvent{List: *list, Item: *item},
+		Old:       lastStatus,
+		New:       isPremium,
+	}}, true
 }
 
 func (w *PremiumWorker) sendNotification(list *database.List, item *database.ListItem, isPremium bool) {
@@ -0,0 +1,172 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+)
+
+const presenceWorkerName = "presence"
+
+// PresenceWorker posts login and logout alerts for characters in presence
+// lists. It does not poll the Tibia API; it reacts to events published by
+// the online tracker.
+type PresenceWorker struct {
+	session         *discordgo.Session
+	listRepo        *repositories.ListRepository
+	itemRepo        *repositories.ListItemRepository
+	events          <-chan Event
+	refreshInterval time.Duration
+	watchers        map[string][]database.List
+}
+
+func NewPresenceWorker(session *discordgo.Session, bus *EventBus) *PresenceWorker {
+	return &PresenceWorker{
+		session:         session,
+		listRepo:        repositories.NewListRepository(),
+		itemRepo:        repositories.NewListItemRepository(),
+		events:          bus.Subscribe(EventLogin, EventLogout),
+		refreshInterval: 1 * time.Minute,
+		watchers:        make(map[string][]database.List),
+	}
+}
+
+func (w *PresenceWorker) Name() string {
+	return presenceWorkerName
+}
+
+func (w *PresenceWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.refreshInterval)
+	defer ticker.Stop()
+
+	w.refreshWatchers()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.refreshWatchers()
+		case event := <-w.events:
+			if presence, ok := event.(PresenceEvent); ok {
+				w.handleEvent(presence)
+			}
+		}
+	}
+}
+
+// refreshWatchers rebuilds the index of watched character names to the
+// presence lists that track them.
+func (w *PresenceWorker) refreshWatchers() {
+	lists, err := w.listRepo.FindByType("presence")
+	if err != nil {
+		logger.Worker(presenceWorkerName, "Error fetching lists: %v", err)
+		return
+	}
+
+	watchers := make(map[string][]database.List)
+	for _, list := range lists {
+		items, err := w.itemRepo.FindByListID(list.ID)
+		if err != nil {
+			logger.Worker(presenceWorkerName, "Error fetching items for list %d: %v", list.ID, err)
+			continue
+		}
+
+		for _, item := range items {
+			key := strings.ToLower(strings.TrimSpace(item.Name))
+			watchers[key] = append(watchers[key], list)
+		}
+	}
+
+	w.watchers = watchers
+}
+
+func (w *PresenceWorker) handleEvent(event PresenceEvent) {
+	lists := w.watchers[strings.ToLower(event.Name)]
+	if len(lists) == 0 {
+		return
+	}
+
+	logger.Worker(presenceWorkerName, "%s %s", event.Name, event.Type)
+
+	for i := range lists {
+		w.sendNotification(&lists[i], event)
+	}
+}
+
+// formatSessionLength renders a session duration as e.g. "2h 05m".
+func formatSessionLength(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", hours, minutes)
+}
+
+func (w *PresenceWorker) sendNotification(list *database.List, event PresenceEvent) {
+	var embed *discordgo.MessageEmbed
+
+	if event.Type == EventLogin {
+		embed = &discordgo.MessageEmbed{
+			Title:       "🟢 Logged In",
+			Description: fmt.Sprintf("**%s** is now online", event.Name),
+			Color:       0x00FF00,
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:   "Level",
+					Value:  fmt.Sprintf("%d", event.Level),
+					Inline: true,
+				},
+				{
+					Name:   "Vocation",
+					Value:  event.Vocation,
+					Inline: true,
+				},
+			},
+		}
+	} else {
+		sessionLength := "Unknown"
+		if event.LoginAt != nil {
+			sessionLength = formatSessionLength(event.At.Sub(*event.LoginAt))
+		}
+		embed = &discordgo.MessageEmbed{
+			Title:       "🔴 Logged Out",
+			Description: fmt.Sprintf("**%s** went offline", event.Name),
+			Color:       0xFF0000,
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:   "Session Length",
+					Value:  sessionLength,
+					Inline: true,
+				},
+			},
+		}
+	}
+
+	embed.Timestamp = event.At.Format(time.RFC3339)
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: "Presence Alert",
+	}
+
+	content := ""
+	if list.NotifyEveryone {
+		content = "@everyone"
+	}
+
+	_, err := w.session.ChannelMessageSendComplex(list.ChannelID, &discordgo.MessageSend{
+		Content: content,
+		Embed:   embed,
+	})
+
+	if err != nil {
+		logger.Error("Error sending notification: %v", err)
+	}
+}
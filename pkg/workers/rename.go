@@ -0,0 +1,194 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/tibia"
+)
+
+const (
+	renameWorkerName = "rename"
+
+	formerNameCheckWorkerName = "former-name-check"
+)
+
+// RenameWorker keeps tracking continuous across character renames: it merges
+// the player records, renames list items and tells every affected channel.
+type RenameWorker struct {
+	session    *discordgo.Session
+	playerRepo *repositories.PlayerRepository
+	itemRepo   *repositories.ListItemRepository
+	events     <-chan Event
+}
+
+func NewRenameWorker(session *discordgo.Session, bus *EventBus) *RenameWorker {
+	return &RenameWorker{
+		session:    session,
+		playerRepo: repositories.NewPlayerRepository(),
+		itemRepo:   repositories.NewListItemRepository(),
+		events:     bus.Subscribe(EventCharacterRenamed),
+	}
+}
+
+func (w *RenameWorker) Name() string {
+	return renameWorkerName
+}
+
+func (w *RenameWorker) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-w.events:
+			if renamed, ok := event.(CharacterRenamedEvent); ok {
+				w.handleRename(renamed.OldName, renamed.NewName)
+			}
+		}
+	}
+}
+
+func (w *RenameWorker) handleRename(oldName, newName string) {
+	if err := w.playerRepo.MergeRename(oldName, newName); err != nil {
+		logger.Worker(renameWorkerName, "Error merging players %s -> %s: %v", oldName, newName, err)
+		return
+	}
+
+	items, err := w.itemRepo.RenameAll(oldName, newName)
+	if err != nil {
+		logger.Worker(renameWorkerName, "Error renaming list items %s -> %s: %v", oldName, newName, err)
+		return
+	}
+
+	logger.Worker(renameWorkerName, "Renamed %s to %s in %d list items", oldName, newName, len(items))
+
+	notified := make(map[string]bool)
+	for _, item := range items {
+		if notified[item.List.ChannelID] {
+			continue
+		}
+		notified[item.List.ChannelID] = true
+
+		embed := &discordgo.MessageEmbed{
+			Title:       "✏️ Character Renamed",
+			Description: fmt.Sprintf("**%s** is now known as **%s**. This list has been updated.", oldName, newName),
+			Color:       0x5865F2,
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:   "Former Name",
+					Value:  oldName,
+					Inline: true,
+				},
+				{
+					Name:   "New Name",
+					Value:  newName,
+					Inline: true,
+				},
+			},
+			Timestamp: time.Now().Format(time.RFC3339),
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: "Rename Alert",
+			},
+		}
+
+		_, err := w.session.ChannelMessageSendComplex(item.List.ChannelID, &discordgo.MessageSend{
+			Embed: embed,
+		})
+		if err != nil {
+			logger.Error("Error sending notification: %v", err)
+		}
+	}
+}
+
+// FormerNameCheckWorker looks for renames among the characters the refresh
+// stage never fetches, such as those only on level-change, presence, exp-lock
+// or scanner lists. Renames are rare, so it runs far less often.
+type FormerNameCheckWorker struct {
+	itemRepo     *repositories.ListItemRepository
+	tibiaClient  *tibia.Client
+	bus          *EventBus
+	pollInterval time.Duration
+}
+
+func NewFormerNameCheckWorker(tibiaClient *tibia.Client, bus *EventBus) *FormerNameCheckWorker {
+	return &FormerNameCheckWorker{
+		itemRepo:     repositories.NewListItemRepository(),
+		tibiaClient:  tibiaClient,
+		bus:          bus,
+		pollInterval: 6 * time.Hour,
+	}
+}
+
+func (w *FormerNameCheckWorker) Name() string {
+	return formerNameCheckWorkerName
+}
+
+func (w *FormerNameCheckWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	w.checkNames(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.checkNames(ctx)
+		}
+	}
+}
+
+func (w *FormerNameCheckWorker) checkNames(ctx context.Context) {
+	// Characters on these lists are fetched every refresh cycle, which
+	// already detects their renames.
+	refreshed := make([]string, 0, len(characterDiffers))
+	for listType := range characterDiffers {
+		refreshed = append(refreshed, listType)
+	}
+
+	names, err := w.itemRepo.FindNamesExcept(refreshed)
+	if err != nil {
+		logger.Worker(formerNameCheckWorkerName, "Error fetching item names: %v", err)
+		return
+	}
+
+	seen := make(map[string]bool, len(names))
+	checked := 0
+
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		character, err := w.tibiaClient.GetCharacterContext(ctx, name)
+		if errors.Is(err, tibia.ErrCircuitOpen) {
+			logger.Worker(formerNameCheckWorkerName, "Tibia API unavailable, stopping after %d characters", checked)
+			return
+		}
+		if err != nil {
+			logger.Worker(formerNameCheckWorkerName, "Error fetching character %s: %v", name, err)
+			continue
+		}
+		checked++
+
+		if !strings.EqualFold(character.Name, name) && character.WasNamed(name) {
+			logger.Worker(formerNameCheckWorkerName, "%s was renamed to %s", name, character.Name)
+			w.bus.Publish(ctx, CharacterRenamedEvent{OldName: name, NewName: character.Name})
+		}
+	}
+
+	logger.Worker(formerNameCheckWorkerName, "Checked %d characters for renames", checked)
+}
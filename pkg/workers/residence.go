@@ -2,32 +2,24 @@ package workers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/ethaan/discord-api/pkg/database"
 	"github.com/ethaan/discord-api/pkg/logger"
-	"github.com/ethaan/discord-api/pkg/repositories"
 	"github.com/ethaan/discord-api/pkg/tibia"
 )
 
 type ResidenceWorker struct {
-	session      *discordgo.Session
-	listRepo     *repositories.ListRepository
-	itemRepo     *repositories.ListItemRepository
-	tibiaClient  *tibia.Client
-	pollInterval time.Duration
+	session *discordgo.Session
+	events  <-chan Event
 }
 
-func NewResidenceWorker(session *discordgo.Session, tibiaAPIURL string) *ResidenceWorker {
+func NewResidenceWorker(session *discordgo.Session, bus *EventBus) *ResidenceWorker {
 	return &ResidenceWorker{
-		session:      session,
-		listRepo:     repositories.NewListRepository(),
-		itemRepo:     repositories.NewListItemRepository(),
-		tibiaClient:  tibia.NewClient(tibiaAPIURL),
-		pollInterval: 30 * time.Second,
+		session: session,
+		events:  bus.Subscribe(EventResidenceChanged),
 	}
 }
 
@@ -36,86 +28,40 @@ func (w *ResidenceWorker) Name() string {
 }
 
 func (w *ResidenceWorker) Run(ctx context.Context) {
-	ticker := time.NewTicker(w.pollInterval)
-	defer ticker.Stop()
-
-	w.checkResidenceStatus()
-
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			w.checkResidenceStatus()
-		}
-	}
-}
-
-func (w *ResidenceWorker) checkResidenceStatus() {
-	lists, err := w.listRepo.FindByType("residence-change")
-	if err != nil {
-		logger.Worker("residence-change", "Error fetching lists: %v", err)
-		return
-	}
-
-	logger.Worker("residence-change", "Checking %d lists", len(lists))
-
-	for _, list := range lists {
-		items, err := w.itemRepo.FindByListID(list.ID)
-		if err != nil {
-			logger.Worker("residence-change", "Error fetching items for list %d: %v", list.ID, err)
-			continue
-		}
-
-		for _, item := range items {
-			w.checkCharacter(&list, &item)
-			time.Sleep(1 * time.Second)
+		case event := <-w.events:
+			if changed, ok := event.(ResidenceChangedEvent); ok {
+				w.sendNotification(&changed.List, &changed.Item, changed.Old, changed.New)
+			}
 		}
 	}
 }
 
-func (w *ResidenceWorker) checkCharacter(list *database.List, item *database.ListItem) {
-	character, err := w.tibiaClient.GetCharacter(item.Name)
-	if err != nil {
-		logger.Worker("residence-change", "Error fetching character %s: %v", item.Name, err)
-		return
-	}
-
+func diffResidence(list *database.List, item *database.ListItem, character *tibia.Character, metadata map[string]interface{}) ([]Event, bool) {
 	currentResidence := character.Residence
 
-	var metadata map[string]interface{}
-	if err := json.Unmarshal(item.Metadata, &metadata); err != nil {
-		metadata = make(map[string]interface{})
-	}
-
 	lastResidence, hasResidence := metadata["residence"].(string)
 
 	if !hasResidence {
 		metadata["residence"] = currentResidence
-		w.updateMetadata(item, metadata)
 		logger.Worker("residence-change", "Initial residence for %s: %s", item.Name, currentResidence)
-		return
+		return nil, true
 	}
 
-	if lastResidence != currentResidence {
-		logger.Worker("residence-change", "Residence changed for %s: %s -> %s", item.Name, lastResidence, currentResidence)
-		w.sendNotification(list, item, lastResidence, currentResidence)
-		metadata["residence"] = currentResidence
-		w.updateMetadata(item, metadata)
+	if lastResidence == currentResidence {
+		return nil, false
 	}
-}
 
-func (w *ResidenceWorker) updateMetadata(item *database.ListItem, metadata map[string]interface{}) {
-	metadataJSON, err := json.Marshal(metadata)
-	if err != nil {
-		logger.Error("Error encoding metadata: %v", err)
-		return
-	}
-
-	item.Metadata = metadataJSON
-	if err := w.itemRepo.Update(item); err != nil {
-		logger.Error("Error updating item: %v", err)
-	}
+	logger.Worker("residence-change", "Residence changed for %s: %s -> %s", item.Name, lastResidence, currentResidence)
+	metadata["residence"] = currentResidence
+	return []Event{ResidenceChangedEvent{
+		ItemEvent: ItemEvent{List: *list, Item: *item},
+		Old:       lastResidence,
+		New:       currentResidence,
+	}}, true
 }
 
 func (w *ResidenceWorker) sendNotification(list *database.List, item *database.ListItem, oldResidence, newResidence string) {
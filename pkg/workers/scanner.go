@@ -0,0 +1,282 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ethaan/discord-api/pkg/database"
+	"github.com/ethaan/discord-api/pkg/logger"
+	"github.com/ethaan/discord-api/pkg/repositories"
+	"github.com/ethaan/discord-api/pkg/services"
+)
+
+const scannerWorkerName = "scanner"
+
+// minReportedConfidence is the lowest band the scanner posts about; low
+// confidence candidates are mostly chance and would flood the channel.
+const minReportedConfidence = services.ConfidenceMedium
+
+// ScannerWorker re-scans every target on scanner lists once per
+// rescanInterval and posts when a candidate first reaches a reportable
+// confidence band or moves into a higher one. The best band each candidate
+// has reached is kept in the item's metadata.
+type ScannerWorker struct {
+	session        *discordgo.Session
+	listRepo       *repositories.ListRepository
+	itemRepo       *repositories.ListItemRepository
+	sessionRepo    *repositories.OnlineSessionRepository
+	playerRepo     *repositories.PlayerRepository
+	pollInterval   time.Duration
+	rescanInterval time.Duration
+}
+
+// scannerChange is a candidate whose confidence band went up since the
+// previous scan. Previous is empty for new candidates.
+type scannerChange struct {
+	Result   repositories.ScanResult
+	Previous string
+	Current  string
+}
+
+func NewScannerWorker(session *discordgo.Session) *ScannerWorker {
+	return &ScannerWorker{
+		session:        session,
+		listRepo:       repositories.NewListRepository(),
+		itemRepo:       repositories.NewListItemRepository(),
+		sessionRepo:    repositories.NewOnlineSessionRepository(),
+		playerRepo:     repositories.NewPlayerRepository(),
+		pollInterval:   1 * time.Hour,
+		rescanInterval: 24 * time.Hour,
+	}
+}
+
+func (w *ScannerWorker) Name() string {
+	return scannerWorkerName
+}
+
+func (w *ScannerWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	w.scanTargets(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.scanTargets(ctx)
+		}
+	}
+}
+
+func (w *ScannerWorker) scanTargets(ctx context.Context) {
+	lists, err := w.listRepo.FindByType("scanner")
+	if err != nil {
+		logger.Worker(scannerWorkerName, "Error fetching lists: %v", err)
+		return
+	}
+
+	for _, list := range lists {
+		items, err := w.itemRepo.FindByListID(list.ID)
+		if err != nil {
+			logger.Worker(scannerWorkerName, "Error fetching items for list %d: %v", list.ID, err)
+			continue
+		}
+
+		if len(items) == 0 {
+			continue
+		}
+
+		settings := services.GuildScanSettings(list.GuildID)
+
+		names := make([]string, len(items))
+		for i, item := range items {
+			names[i] = item.Name
+		}
+
+		// Items keep the name as typed in /add, so targets are matched to
+		// tracked players case-insensitively.
+		players, err := w.playerRepo.FindByNames(names)
+		if err != nil {
+			logger.Worker(scannerWorkerName, "Error resolving targets for list %d: %v", list.ID, err)
+			continue
+		}
+
+		targets := make(map[string]*database.Player, len(players))
+		for i := range players {
+			targets[strings.ToLower(players[i].Name)] = &players[i]
+		}
+
+		for _, item := range items {
+			if ctx.Err() != nil {
+				return
+			}
+			w.scanTarget(&list, &item, targets[strings.ToLower(strings.TrimSpace(item.Name))], settings)
+		}
+	}
+}
+
+// scanTarget scans the tracked player an item refers to. target is nil
+// when the online tracker has never seen the item's name.
+func (w *ScannerWorker) scanTarget(list *database.List, item *database.ListItem, target *database.Player, settings services.ScanSettings) {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(item.Metadata, &metadata); err != nil {
+		metadata = make(map[string]interface{})
+	}
+
+	if target == nil {
+		logger.Worker(scannerWorkerName, "Skipping %s: not tracked", item.Name)
+
+		// Reported once, in case the name is misspelled or on another world
+		if reported, _ := metadata["untracked"].(bool); !reported {
+			w.sendUntrackedNotification(list, item)
+			metadata["untracked"] = true
+			w.updateMetadata(item, metadata)
+		}
+		return
+	}
+	delete(metadata, "untracked")
+
+	if lastScannedAt, ok := metadata["last_scanned_at"].(string); ok {
+		if at, err := time.Parse(time.RFC3339, lastScannedAt); err == nil && time.Since(at) < w.rescanInterval {
+			return
+		}
+	}
+
+	results, err := w.sessionRepo.ScanCharacter(target, settings.Options)
+	if err != nil {
+		logger.Worker(scannerWorkerName, "Error scanning %s: %v", item.Name, err)
+		return
+	}
+
+	previous, _ := metadata["candidates"].(map[string]interface{})
+	current := make(map[string]interface{}, len(results))
+	var changes []scannerChange
+
+	// Candidates keep the best band they have reached, so one hovering
+	// around a band boundary is only reported the first time it crosses.
+	for name, level := range previous {
+		current[name] = level
+	}
+
+	for _, result := range results {
+		// Moderators already reviewed labeled pairs
+		if result.Label != "" {
+			continue
+		}
+
+		level := settings.Confidence(result.Score)
+		previousLevel, _ := previous[result.CharacterName].(string)
+
+		if services.ConfidenceRank(level) <= services.ConfidenceRank(previousLevel) {
+			continue
+		}
+		current[result.CharacterName] = level
+
+		if services.ConfidenceRank(level) < services.ConfidenceRank(minReportedConfidence) {
+			continue
+		}
+
+		changes = append(changes, scannerChange{
+			Result:   result,
+			Previous: previousLevel,
+			Current:  level,
+		})
+	}
+
+	logger.Worker(scannerWorkerName, "Scanned %s: %d candidates, %d changes", item.Name, len(results), len(changes))
+
+	if len(changes) > 0 {
+		w.sendNotification(list, item, changes)
+	}
+
+	metadata["candidates"] = current
+	metadata["last_scanned_at"] = time.Now().Format(time.RFC3339)
+	w.updateMetadata(item, metadata)
+}
+
+func (w *ScannerWorker) sendUntrackedNotification(list *database.List, item *database.ListItem) {
+	embed := &discordgo.MessageEmbed{
+		Title:       "❔ Scanner Target Not Tracked",
+		Description: fmt.Sprintf("**%s** has never been seen online by the tracker, so it cannot be scanned yet. Check the spelling and that its world is tracked.", item.Name),
+		Color:       0x95A5A6,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Scanner Alert",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	_, err := w.session.ChannelMessageSendComplex(list.ChannelID, &discordgo.MessageSend{
+		Embed: embed,
+	})
+
+	if err != nil {
+		logger.Error("Error sending notification: %v", err)
+	}
+}
+
+func (w *ScannerWorker) updateMetadata(item *database.ListItem, metadata map[string]interface{}) {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		logger.Error("Error encoding metadata: %v", err)
+		return
+	}
+
+	item.Metadata = metadataJSON
+	if err := w.itemRepo.Update(item); err != nil {
+		logger.Error("Error updating item: %v", err)
+	}
+}
+
+var confidenceLabels = map[string]string{
+	services.ConfidenceVeryHigh: "🔴 Very High",
+	services.ConfidenceHigh:     "🟠 High",
+	services.ConfidenceMedium:   "🟡 Medium",
+	services.ConfidenceLow:      "⚪ Low",
+}
+
+func (w *ScannerWorker) sendNotification(list *database.List, item *database.ListItem, changes []scannerChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Result.Score > changes[j].Result.Score
+	})
+
+	var lines []string
+	for _, change := range changes {
+		status := fmt.Sprintf("🆕 %s", confidenceLabels[change.Current])
+		if change.Previous != "" {
+			status = fmt.Sprintf("⬆️ %s → %s", confidenceLabels[change.Previous], confidenceLabels[change.Current])
+		}
+		lines = append(lines, fmt.Sprintf("**%s**: %s (score %.1f, %d transitions)",
+			change.Result.CharacterName, status, change.Result.Score, change.Result.AdjacentCount))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🕵️ Possible alts of %s", item.Name),
+		Description: truncateFieldLines(lines),
+		Color:       0xFFA500,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Scanner Alert • use /scan-explain %s <candidate> for evidence", item.Name),
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	content := ""
+	if list.NotifyEveryone {
+		content = "@everyone"
+	}
+
+	_, err := w.session.ChannelMessageSendComplex(list.ChannelID, &discordgo.MessageSend{
+		Content: content,
+		Embed:   embed,
+	})
+
+	if err != nil {
+		logger.Error("Error sending notification: %v", err)
+	}
+}